@@ -0,0 +1,29 @@
+package api
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec is the OpenAPI document of the HTTP API.
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// Load parses and validates Spec.
+func Load() (*openapi3.T, error) {
+	const op = "api.Load"
+
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse spec: %w", op, err)
+	}
+
+	if err := doc.Validate(openapi3.NewLoader().Context); err != nil {
+		return nil, fmt.Errorf("%s: invalid spec: %w", op, err)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,435 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/api"
+	"stellar_journal/internal/apod_backfill"
+	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/health"
+	backfillmocks "stellar_journal/internal/http-server/handlers/admin/backfill/mocks"
+	adminjournalmocks "stellar_journal/internal/http-server/handlers/admin/journal/mocks"
+	workersmocks "stellar_journal/internal/http-server/handlers/admin/workers/mocks"
+	healthmocks "stellar_journal/internal/http-server/handlers/health/mocks"
+	allmocks "stellar_journal/internal/http-server/handlers/journal/get/all/mocks"
+	bydatemocks "stellar_journal/internal/http-server/handlers/journal/get/by_date/mocks"
+	historymocks "stellar_journal/internal/http-server/handlers/journal/get/history/mocks"
+	imagemocks "stellar_journal/internal/http-server/handlers/journal/get/image/mocks"
+	searchmocks "stellar_journal/internal/http-server/handlers/journal/get/search/mocks"
+	authmocks "stellar_journal/internal/http-server/middleware/auth/mocks"
+	mwRateLimit "stellar_journal/internal/http-server/middleware/rate_limit"
+	httpRouter "stellar_journal/internal/http-server/router"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/api_key"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/rate_limit"
+	"stellar_journal/internal/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminKey    = "sj_admin"
+	quotaKey    = "sj_quota"
+	limitedAddr = "203.0.113.9"
+)
+
+// limiter denies the requests of limitedAddr.
+type limiter struct{}
+
+func (limiter) Allow(_ context.Context, key string) (rate_limit.Decision, error) {
+	if key == "ip:"+limitedAddr {
+		return rate_limit.Decision{Limit: 60, Reset: time.Second, RetryAfter: time.Second}, nil
+	}
+
+	return rate_limit.Decision{Allowed: true, Limit: 60, Remaining: 59, Reset: time.Second}, nil
+}
+
+func sampleAPOD() stellar_journal_models.APOD {
+	apod := stellar_journal_models.APOD{
+		Source:         stellar_journal_models.SourceNASAAPOD,
+		Copyright:      "Jane Doe",
+		Date:           apod_date.MustParse("2024-01-02"),
+		Explanation:    "A galaxy.",
+		Hdurl:          "https://apod.nasa.gov/apod/image/2401/galaxy.jpg",
+		MediaType:      stellar_journal_models.MediaTypeImage,
+		ServiceVersion: "v1",
+		Title:          "Galaxy",
+		Url:            "https://apod.nasa.gov/apod/image/2401/galaxy_1024.jpg",
+		Id:             1,
+	}
+	apod.Media = stellar_journal_models.NewMedia(apod.MediaType, apod.Url, apod.Hdurl, apod.ThumbnailUrl)
+	apod.AddDerivative(320, "jpeg")
+	apod.AddDerivative(320, "webp")
+
+	return apod
+}
+
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekCloser) Close() error { return nil }
+
+// newServer serves the routes of the API as main does, with the journal behind
+// authentication and rate limiting, on storage mocks.
+func newServer(t *testing.T) http.Handler {
+	apod := sampleAPOD()
+	video := apod
+	video.MediaType, video.Url, video.Hdurl, video.Derivatives, video.Srcset = stellar_journal_models.MediaTypeVideo, "https://www.youtube.com/embed/abc", "", nil, nil
+	video.Media = stellar_journal_models.NewMedia(video.MediaType, video.Url, video.Hdurl, video.ThumbnailUrl)
+
+	journal := allmocks.NewJournalGetter(t)
+	journal.On("GetJournal", mock.Anything, mock.MatchedBy(func(query storage.JournalQuery) bool {
+		return query.Source == stellar_journal_models.SourceWikimedia
+	})).Return(nil, errors.New("db is down")).Maybe()
+	journal.On("GetJournal", mock.Anything, mock.Anything).Return(&storage.JournalPage{
+		APODs:      []stellar_journal_models.APOD{apod, video},
+		Total:      10,
+		NextCursor: storage.Cursor{Date: video.Date, Source: video.Source},
+	}, nil).Maybe()
+
+	searcher := searchmocks.NewJournalSearcher(t)
+	searcher.On("SearchJournal", mock.Anything, "galaxy", mock.Anything).Return([]stellar_journal_models.SearchResult{
+		{APOD: apod, Rank: 0.5, TitleHighlight: "<mark>Galaxy</mark>", Snippet: "A <mark>galaxy</mark>."},
+	}, nil).Maybe()
+	searcher.On("SearchJournal", mock.Anything, "broken", mock.Anything).Return(nil, errors.New("db is down")).Maybe()
+
+	getter := bydatemocks.NewAPODByDateGetter(t)
+	getter.On("GetAPOD", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-02")).Return(&apod, nil).Maybe()
+	getter.On("GetAPOD", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-03")).Return(nil, storage.ErrAPODNotFound).Maybe()
+	getter.On("GetAPOD", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-04")).Return(nil, errors.New("db is down")).Maybe()
+	getter.On("GetRandomAPOD", mock.Anything, mock.Anything).Return(&video, nil).Maybe()
+
+	content := []byte("\xff\xd8\xff\xe0 fake jpeg")
+	media := imagemocks.NewAPODMediaGetter(t)
+	media.On("GetAPODMedia", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-02"), mock.Anything).Return(&stellar_journal_models.APODMedia{
+		StorageKey:  "2024-01-02/hd.jpg",
+		Checksum:    "abc",
+		ByteSize:    int64(len(content)),
+		ContentType: "image/jpeg",
+	}, nil).Maybe()
+	media.On("GetAPODMedia", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-03"), mock.Anything).Return(nil, storage.ErrMediaNotFound).Maybe()
+	blobs := imagemocks.NewBlobGetter(t)
+	blobs.On("Get", mock.Anything).Return(readSeekCloser{bytes.NewReader(content)}, nil).Maybe()
+
+	checker := healthmocks.NewChecker(t)
+	checker.On("Check", mock.Anything).Return(health.Report{Status: health.StatusDown, Checks: map[string]health.Result{
+		"database":    {Status: health.StatusDown, Error: "connection refused", Details: map[string]any{"latency_ms": 2}},
+		"latest_apod": {Status: health.StatusDegraded, Error: "no entries", Details: map[string]any{"source": "nasa_apod"}},
+	}}).Maybe()
+
+	revisions := historymocks.NewAPODRevisionsGetter(t)
+	revisions.On("GetAPODRevisions", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-02")).Return([]stellar_journal_models.APODRevision{{
+		Id:             1,
+		Copyright:      apod.Copyright,
+		Explanation:    "A galaxxy.",
+		Hdurl:          apod.Hdurl,
+		MediaType:      apod.MediaType,
+		ServiceVersion: apod.ServiceVersion,
+		Title:          apod.Title,
+		Url:            apod.Url,
+		UpdatedAt:      time.Date(2024, time.January, 2, 5, 0, 0, 0, time.UTC),
+		ReplacedAt:     time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC),
+	}}, nil).Maybe()
+	revisions.On("GetAPODRevisions", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-03")).Return(nil, storage.ErrAPODNotFound).Maybe()
+
+	fetcher := adminjournalmocks.NewFetcher(t)
+	fetcher.On("Fetch", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-02"), true).Return(&apod, false, nil).Maybe()
+	fetcher.On("Fetch", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-02"), false).Return(nil, false, storage.ErrAPODExists).Maybe()
+	fetcher.On("Fetch", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-03"), false).Return(&apod, true, nil).Maybe()
+	fetcher.On("Fetch", mock.Anything, stellar_journal_models.SourceBing, mock.Anything, mock.Anything).Return(nil, false, apod_worker.ErrUnknownSource).Maybe()
+
+	deleter := adminjournalmocks.NewDeleter(t)
+	deleter.On("DeleteAPOD", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-02")).Return(nil).Maybe()
+	deleter.On("DeleteAPOD", mock.Anything, mock.Anything, apod_date.MustParse("2024-01-03")).Return(storage.ErrAPODNotFound).Maybe()
+
+	job := apod_backfill.Job{
+		From:      apod_date.MustParse("2024-01-01"),
+		To:        apod_date.MustParse("2024-01-31"),
+		Status:    apod_backfill.JobRunning,
+		StartedAt: time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC),
+	}
+	finished := job
+	finished.Status, finished.FinishedAt, finished.Error = apod_backfill.JobFailed, job.StartedAt.Add(time.Minute), "rate limited"
+	finished.Stats = apod_backfill.Stats{Chunks: 2, Inserted: 20}
+	runner := backfillmocks.NewRunner(t)
+	runner.On("Start", apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-31")).Return(job, nil).Maybe()
+	runner.On("Start", apod_date.MustParse("2023-01-01"), mock.Anything).Return(apod_backfill.Job{}, apod_backfill.ErrRunning).Maybe()
+	runner.On("Job").Return(finished, true).Maybe()
+
+	stats := workersmocks.NewStatsGetter(t)
+	stats.On("Stats").Return(map[string]apod_worker.Stats{
+		stellar_journal_models.SourceNASAAPOD: {Successes: 3, LastRun: job.StartedAt, LastSuccess: job.StartedAt, NextRun: job.StartedAt.Add(time.Hour)},
+		stellar_journal_models.SourceBing:     {Failures: 1, LastRun: job.StartedAt, LastError: "connection refused"},
+	}).Maybe()
+
+	// The quota key has used up its quota and the admin key may use the admin
+	// routes; any other key is valid.
+	keys := authmocks.NewKeyStore(t)
+	keys.On("GetAPIKeyByHash", mock.Anything, api_key.Hash(adminKey)).Return(&stellar_journal_models.APIKey{ID: 3, Admin: true}, nil).Maybe()
+	keys.On("GetAPIKeyByHash", mock.Anything, api_key.Hash(quotaKey)).Return(&stellar_journal_models.APIKey{ID: 2, DailyQuota: 10}, nil).Maybe()
+	keys.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(&stellar_journal_models.APIKey{ID: 1}, nil).Maybe()
+	keys.On("RecordAPIKeyUsage", mock.Anything, 2, mock.Anything).Return(int64(11), nil).Maybe()
+	keys.On("RecordAPIKeyUsage", mock.Anything, 1, mock.Anything).Return(int64(1), nil).Maybe()
+	keys.On("RecordAPIKeyUsage", mock.Anything, 3, mock.Anything).Return(int64(1), nil).Maybe()
+
+	log := slogdiscard.NewDiscardLogger()
+	router := chi.NewRouter()
+	httpRouter.Mount(router, httpRouter.Options{
+		Log:     log,
+		Checker: checker,
+		Keys:    keys,
+		Clock:   clock.System,
+		Auth:    true,
+		LimitIP: mwRateLimit.New(log, limiter{}, rate_limit.Policy{Limit: 60, Window: time.Minute}, mwRateLimit.ByIP(nil)),
+		Journal: httpRouter.Journal{
+			Pages:     journal,
+			Entries:   getter,
+			Searcher:  searcher,
+			Revisions: revisions,
+			Media:     media,
+			Blobs:     blobs,
+			// The journal requires a key, so its responses are private as in
+			// main.
+			CachePolicy: http_cache.Policy{MaxAge: time.Hour, RecentMaxAge: time.Minute, Private: true},
+		},
+		Admin: &httpRouter.Admin{
+			Fetcher:      fetcher,
+			FetchTimeout: time.Minute,
+			Deleter:      deleter,
+			Backfills:    runner,
+			Workers:      stats,
+		},
+	})
+
+	return router
+}
+
+type specCase struct {
+	// method is GET unless set.
+	method      string
+	url         string
+	accept      string
+	ifNoneMatch string
+	// apiKey is sent in X-API-Key unless authorization is set; "-" sends no
+	// key.
+	apiKey        string
+	authorization string
+	remoteAddr    string
+	status        int
+	// cacheControl is checked unless empty.
+	cacheControl string
+}
+
+func (tc specCase) request() *http.Request {
+	method := tc.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, tc.url, nil)
+	if tc.accept != "" {
+		req.Header.Set("Accept", tc.accept)
+	}
+	if tc.ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+	}
+	if tc.remoteAddr != "" {
+		req.RemoteAddr = tc.remoteAddr
+	}
+	switch {
+	case tc.authorization != "":
+		req.Header.Set("Authorization", tc.authorization)
+	case tc.apiKey == "":
+		req.Header.Set("X-API-Key", "sj_test")
+	case tc.apiKey != "-":
+		req.Header.Set("X-API-Key", tc.apiKey)
+	}
+
+	return req
+}
+
+// checkSpec serves the requests of cases and fails when a request or response
+// does not match the OpenAPI document.
+func checkSpec(t *testing.T, cases []specCase) {
+	t.Helper()
+
+	doc, err := api.Load()
+	require.NoError(t, err)
+	specRouter, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+	server := newServer(t)
+
+	for _, tc := range cases {
+		req := tc.request()
+		route, pathParams, err := specRouter.FindRoute(req)
+		require.NoError(t, err, tc.url)
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{SkipSettingDefaults: true, AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		if tc.status < http.StatusBadRequest {
+			require.NoError(t, openapi3filter.ValidateRequest(context.Background(), requestInput), tc.url)
+		}
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		require.Equal(t, tc.status, rr.Code, tc.url)
+		if tc.cacheControl != "" {
+			require.Equal(t, tc.cacheControl, rr.Header().Get("Cache-Control"), tc.url)
+		}
+
+		options := &openapi3filter.Options{IncludeResponseStatus: true}
+		if strings.HasPrefix(rr.Header().Get("Content-Type"), "image/") {
+			options.ExcludeResponseBody = true
+		}
+		err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 rr.Code,
+			Header:                 rr.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rr.Body.Bytes())),
+			Options:                options,
+		})
+		require.NoError(t, err, "%s: %s", tc.url, rr.Body.String())
+	}
+}
+
+var healthCases = []specCase{
+	{url: "/healthz", status: http.StatusOK},
+	{url: "/readyz", status: http.StatusServiceUnavailable},
+}
+
+func TestSpecHealth(t *testing.T) {
+	checkSpec(t, healthCases)
+}
+
+var journalCases = []specCase{
+	{url: "/journal", status: http.StatusOK, cacheControl: "private, max-age=60"},
+	{url: "/journal?source=bing&limit=2&order=asc&after=2024-01-01.bing&from=2024-01-01&to=2024-01-31&media_type=image", status: http.StatusOK},
+	{url: "/journal", ifNoneMatch: "*", status: http.StatusNotModified},
+	{url: "/journal?limit=0", status: http.StatusBadRequest},
+	{url: "/journal?limit=0", accept: "application/problem+json", status: http.StatusBadRequest},
+	{url: "/journal?source=wikimedia", status: http.StatusInternalServerError},
+}
+
+func TestSpecJournal(t *testing.T) {
+	checkSpec(t, journalCases)
+}
+
+var searchCases = []specCase{
+	{url: "/journal/search?q=galaxy&limit=5", status: http.StatusOK},
+	{url: "/journal/search?q=broken", status: http.StatusInternalServerError},
+	{url: "/journal/search", status: http.StatusBadRequest},
+}
+
+func TestSpecSearch(t *testing.T) {
+	checkSpec(t, searchCases)
+}
+
+var entryCases = []specCase{
+	{url: "/journal/2024-01-02", status: http.StatusOK, cacheControl: "private, max-age=3600"},
+	{url: "/journal/2024-01-02", ifNoneMatch: "*", status: http.StatusNotModified},
+	{url: "/journal/random?source=nasa_apod", status: http.StatusOK},
+	{url: "/journal/2024-01-03", status: http.StatusNotFound},
+	{url: "/journal/2024-01-03", accept: "application/problem+json", status: http.StatusNotFound},
+	{url: "/journal/2024-01-04", status: http.StatusInternalServerError},
+	{url: "/journal/1990-01-01", status: http.StatusBadRequest},
+}
+
+func TestSpecEntry(t *testing.T) {
+	checkSpec(t, entryCases)
+}
+
+var imageCases = []specCase{
+	{url: "/journal/2024-01-02/image?variant=hd", status: http.StatusOK},
+	{url: "/journal/2024-01-03/image", status: http.StatusNotFound},
+	{url: "/journal/2024-01-02/image?variant=xl", status: http.StatusBadRequest},
+}
+
+func TestSpecImage(t *testing.T) {
+	checkSpec(t, imageCases)
+}
+
+var historyCases = []specCase{
+	{url: "/journal/2024-01-02/history", status: http.StatusOK, cacheControl: "private, max-age=60"},
+	{url: "/journal/2024-01-02/history", ifNoneMatch: "*", status: http.StatusNotModified},
+	{url: "/journal/2024-01-03/history", status: http.StatusNotFound},
+	{url: "/journal/random/history", status: http.StatusBadRequest},
+}
+
+func TestSpecHistory(t *testing.T) {
+	checkSpec(t, historyCases)
+}
+
+var accessCases = []specCase{
+	{url: "/journal", authorization: "Bearer sj_test", status: http.StatusOK},
+	{url: "/journal", apiKey: "-", status: http.StatusUnauthorized},
+	{url: "/journal/2024-01-02", apiKey: "-", accept: "application/problem+json", status: http.StatusUnauthorized},
+	{url: "/journal", apiKey: quotaKey, status: http.StatusTooManyRequests},
+	{url: "/journal/2024-01-02", remoteAddr: limitedAddr + ":1234", status: http.StatusTooManyRequests},
+	{url: "/journal/2024-01-02", apiKey: "-", remoteAddr: limitedAddr + ":1234", status: http.StatusTooManyRequests},
+	{url: "/admin/workers", apiKey: adminKey, remoteAddr: limitedAddr + ":1234", status: http.StatusTooManyRequests},
+}
+
+func TestSpecAccess(t *testing.T) {
+	checkSpec(t, accessCases)
+}
+
+var adminCases = []specCase{
+	{url: "/admin/workers", apiKey: adminKey, status: http.StatusOK},
+	{url: "/admin/workers", status: http.StatusForbidden},
+	{url: "/admin/workers", apiKey: "-", status: http.StatusUnauthorized},
+	{method: http.MethodPost, url: "/admin/journal/2024-01-03/fetch", apiKey: adminKey, status: http.StatusCreated},
+	{method: http.MethodPost, url: "/admin/journal/2024-01-02/fetch?overwrite=true", apiKey: adminKey, status: http.StatusOK},
+	{method: http.MethodPost, url: "/admin/journal/2024-01-02/fetch", apiKey: adminKey, status: http.StatusConflict},
+	{method: http.MethodPost, url: "/admin/journal/2024-01-02/fetch?source=bing", apiKey: adminKey, status: http.StatusBadRequest},
+	{method: http.MethodPost, url: "/admin/journal/2024-01-02/fetch", accept: "application/problem+json", status: http.StatusForbidden},
+	{method: http.MethodDelete, url: "/admin/journal/2024-01-02", apiKey: adminKey, status: http.StatusNoContent},
+	{method: http.MethodDelete, url: "/admin/journal/2024-01-03", apiKey: adminKey, status: http.StatusNotFound},
+	{method: http.MethodPost, url: "/admin/backfill?from=2024-01-01&to=2024-01-31", apiKey: adminKey, status: http.StatusAccepted},
+	{method: http.MethodPost, url: "/admin/backfill?from=2023-01-01", apiKey: adminKey, status: http.StatusConflict},
+	{method: http.MethodPost, url: "/admin/backfill", apiKey: adminKey, status: http.StatusBadRequest},
+	{url: "/admin/backfill", apiKey: adminKey, status: http.StatusOK},
+}
+
+func TestSpecAdmin(t *testing.T) {
+	checkSpec(t, adminCases)
+}
+
+// TestSpecIsCovered fails when a documented path has no case above.
+func TestSpecIsCovered(t *testing.T) {
+	doc, err := api.Load()
+	require.NoError(t, err)
+	specRouter, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	covered := make(map[string]bool)
+	for _, cases := range [][]specCase{healthCases, journalCases, searchCases, entryCases, imageCases, historyCases, accessCases, adminCases} {
+		for _, tc := range cases {
+			route, _, err := specRouter.FindRoute(tc.request())
+			require.NoError(t, err, tc.url)
+			covered[route.Path] = true
+		}
+	}
+
+	for path := range doc.Paths.Map() {
+		require.True(t, covered[path], "%s is not covered", path)
+	}
+}
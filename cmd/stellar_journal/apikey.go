@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"stellar_journal/internal/lib/api_key"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"text/tabwriter"
+	"time"
+)
+
+const cmdAPIKey = "apikey"
+
+type apiKeyStorage interface {
+	CreateAPIKey(ctx context.Context, key *stellar_journal_models.APIKey, hash []byte) error
+	ListAPIKeys(ctx context.Context, now time.Time) ([]stellar_journal_models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	SetAPIKeyQuota(ctx context.Context, id int, dailyQuota int) error
+}
+
+// runAPIKey implements the "apikey" subcommand:
+//
+//	stellar_journal apikey create -name NAME [-quota 0] [-admin]
+//	stellar_journal apikey list
+//	stellar_journal apikey revoke -id ID
+//	stellar_journal apikey quota -id ID -quota N
+//
+// A created key is printed once; only its hash is stored.
+func runAPIKey(ctx context.Context, out io.Writer, storage apiKeyStorage, args []string) error {
+	const op = "cmd/stellar_journal.runAPIKey"
+
+	if len(args) == 0 {
+		return fmt.Errorf("%s: expected create, list, revoke or quota", op)
+	}
+
+	fs := flag.NewFlagSet(cmdAPIKey+" "+args[0], flag.ContinueOnError)
+	name := fs.String("name", "", "name of the client the key is for")
+	id := fs.Int("id", 0, "id of the key, as shown by list")
+	quota := fs.Int("quota", 0, "requests per UTC day, 0 is unlimited")
+	admin := fs.Bool("admin", false, "allow the key to use the /admin routes")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if *quota < 0 {
+		return fmt.Errorf("%s: the quota must not be negative", op)
+	}
+
+	switch args[0] {
+	case "create":
+		if *name == "" {
+			return fmt.Errorf("%s: -name is required", op)
+		}
+
+		raw, err := api_key.Generate()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		key := &stellar_journal_models.APIKey{Name: *name, Prefix: api_key.Prefix(raw), Admin: *admin, DailyQuota: *quota}
+		if err := storage.CreateAPIKey(ctx, key, api_key.Hash(raw)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		fmt.Fprintf(out, "created key %d for %s, store it now, it is not shown again:\n%s\n", key.ID, key.Name, raw)
+	case "list":
+		keys, err := storage.ListAPIKeys(ctx, time.Now())
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tADMIN\tDAILY QUOTA\tREQUESTS TODAY\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%t\t%s\t%d\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.Admin, quotaString(key.DailyQuota), key.RequestsToday, timeString(key.LastUsedAt), timeString(key.RevokedAt))
+		}
+		return tw.Flush()
+	case "revoke":
+		if err := storage.RevokeAPIKey(ctx, *id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		fmt.Fprintf(out, "revoked key %d\n", *id)
+	case "quota":
+		// The flag defaults to unlimited, which must not be set by omission.
+		quotaSet := false
+		fs.Visit(func(f *flag.Flag) {
+			quotaSet = quotaSet || f.Name == "quota"
+		})
+		if !quotaSet {
+			return fmt.Errorf("%s: -quota is required, 0 is unlimited", op)
+		}
+
+		if err := storage.SetAPIKeyQuota(ctx, *id, *quota); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		fmt.Fprintf(out, "set the daily quota of key %d to %s\n", *id, quotaString(*quota))
+	default:
+		return fmt.Errorf("%s: unknown command %q, expected create, list, revoke or quota", op, args[0])
+	}
+
+	return nil
+}
+
+func quotaString(quota int) string {
+	if quota == 0 {
+		return "unlimited"
+	}
+
+	return fmt.Sprint(quota)
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
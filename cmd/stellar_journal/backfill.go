@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"stellar_journal/internal/apod_backfill"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+)
+
+const cmdBackfill = "backfill"
+
+// runBackfill implements the "backfill" subcommand:
+//
+//	stellar_journal backfill [-from 1995-06-16] [-to YYYY-MM-DD] [-chunk 30]
+//
+// An interrupted backfill stops after the current chunk and can be resumed by
+// running it again. Images are archived unless archiver is nil, and the journal
+// pages are invalidated once pictures were saved unless cache is nil, as after
+// backfills through the admin routes.
+func runBackfill(ctx context.Context, log *slog.Logger, provider apod_backfill.RangeProvider, storage apod_backfill.Storage, archiver apod_backfill.MediaArchiver, cache apod_backfill.JournalInvalidator, args []string) error {
+	const op = "cmd/stellar_journal.runBackfill"
+
+	fs := flag.NewFlagSet(cmdBackfill, flag.ContinueOnError)
+	fromFlag := fs.String("from", apod_date.Epoch.String(), "first date to backfill (YYYY-MM-DD)")
+	toFlag := fs.String("to", apod_date.Today().String(), "last date to backfill (YYYY-MM-DD)")
+	chunk := fs.Int("chunk", apod_backfill.DefaultChunkDays, "number of days requested from the NASA API at once")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	from, err := apod_date.Parse(*fromFlag)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	to, err := apod_date.Parse(*toFlag)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("starting backfill", slog.String("from", *fromFlag), slog.String("to", *toFlag))
+
+	stats, err := apod_backfill.NewBackfiller(provider, storage, archiver, log, *chunk).Run(ctx, from, to)
+	// Pictures saved before a failure are in the journal all the same.
+	if stats != nil && stats.Inserted > 0 && cache != nil {
+		if err := cache.InvalidateJournal(context.WithoutCancel(ctx)); err != nil {
+			log.Error("failed to invalidate cached journal pages", sl.Err(err))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info(
+		"backfill finished",
+		slog.Int("chunks", stats.Chunks),
+		slog.Int("complete_chunks", stats.SkippedChunks),
+		slog.Int("inserted", stats.Inserted),
+		slog.Int("skipped", stats.Skipped),
+		slog.Int("no_picture", stats.NoPicture),
+	)
+
+	return nil
+}
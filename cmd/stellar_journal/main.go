@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -11,27 +10,54 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"stellar_journal/api"
+	"stellar_journal/internal/apod_backfill"
 	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/blob_store/filesystem"
+	"stellar_journal/internal/cache_store/lru"
+	"stellar_journal/internal/cache_store/resp"
 	"stellar_journal/internal/config"
-	"stellar_journal/internal/http-server/handlers/journal/get/all"
-	"stellar_journal/internal/http-server/handlers/journal/get/by_date"
+	"stellar_journal/internal/health"
+	"stellar_journal/internal/http-server/handlers/docs"
 	mwLg "stellar_journal/internal/http-server/middleware/logger"
+	mwMetrics "stellar_journal/internal/http-server/middleware/metrics"
+	mwRateLimit "stellar_journal/internal/http-server/middleware/rate_limit"
+	mwTracing "stellar_journal/internal/http-server/middleware/tracing"
+	httpRouter "stellar_journal/internal/http-server/router"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/image_pipeline"
 	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/media_archiver"
+	"stellar_journal/internal/metrics"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers/bing"
+	"stellar_journal/internal/providers/esa_hubble"
+	"stellar_journal/internal/providers/nasa_apod"
+	"stellar_journal/internal/providers/wikimedia"
+	"stellar_journal/internal/rate_limit"
+	"stellar_journal/internal/rate_limit/memory"
+	rlresp "stellar_journal/internal/rate_limit/resp"
+	"stellar_journal/internal/scheduler"
 	"stellar_journal/internal/stellar_api/nasa_api"
+	"stellar_journal/internal/storage/cached"
 	mgr "stellar_journal/internal/storage/migrator"
 	"stellar_journal/internal/storage/postgresql"
+	"stellar_journal/internal/tracing"
+	"stellar_journal/migrations"
+	"sync"
 	"syscall"
 )
 
+const version = "1.0.0"
+
 const (
 	envLocal = "local"
 	envDev   = "dev"
 	envProd  = "prod"
 )
 
-//go:embed migrations/*.sql
-var embeddedFiles embed.FS
-
 func main() {
 	cfg := config.MustLoad()
 
@@ -40,19 +66,62 @@ func main() {
 	log.Info(
 		"starting stellar journal",
 		slog.String("env", cfg.Env),
-		slog.String("version", "1.0.0"),
+		slog.String("version", version),
 	)
 
 	log.Debug("debug messages are enabled")
 
-	db, err := sql.Open("postgres", fmt.Sprintf("user=%s password=%s dbname=%s host=%s sslmode=disable", cfg.Storage.User, cfg.Storage.Password, cfg.Storage.Name, cfg.Storage.Host))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:       cfg.Tracing.Exporter,
+		Endpoint:       cfg.Tracing.Endpoint,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+		ServiceName:    cfg.Tracing.ServiceName,
+		ServiceVersion: version,
+	}, os.Stdout)
+	if err != nil {
+		log.Error("failed to set up tracing", sl.Err(err))
+		os.Exit(1)
+	}
+	defer func() {
+		// Runs after the server and workers stopped, so their spans are flushed.
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.CtxTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Error("failed to flush traces", sl.Err(err))
+		}
+	}()
+
+	// One pool is shared by the migrations, the storage and the backfill.
+	db, err := postgresql.Open(ctx, postgresql.Config{
+		URL:             cfg.Storage.URL,
+		Host:            cfg.Storage.Host,
+		Port:            cfg.Storage.Port,
+		Name:            cfg.Storage.Name,
+		User:            cfg.Storage.User,
+		Password:        cfg.Storage.Password,
+		SSLMode:         cfg.Storage.SSLMode,
+		SSLRootCert:     cfg.Storage.SSLRootCert,
+		MaxOpenConns:    cfg.Storage.Pool.MaxOpenConns,
+		MaxIdleConns:    cfg.Storage.Pool.MaxIdleConns,
+		ConnMaxLifetime: cfg.Storage.Pool.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Storage.Pool.ConnMaxIdleTime,
+		ConnectRetry: backoff.Policy{
+			Initial: cfg.Storage.ConnectRetry.InitialDelay,
+			Max:     cfg.Storage.ConnectRetry.MaxDelay,
+			Jitter:  0.2,
+		},
+		ConnectTimeout: cfg.Storage.ConnectRetry.Timeout,
+	}, log)
 	if err != nil {
 		log.Error("failed to connect to the database", sl.Err(err))
 		os.Exit(1)
 	}
 
 	dbDriver := &postgresql.PostgresDriver{}
-	migrator, err := mgr.NewMigrator(embeddedFiles, "migrations", dbDriver)
+	migrator, err := mgr.NewMigrator(migrations.FS, ".", dbDriver)
 	if err != nil {
 		log.Error("failed to create migrator", sl.Err(err))
 		os.Exit(1)
@@ -70,33 +139,226 @@ func main() {
 		}
 	}(db)
 
-	storage, err := postgresql.NewStorage(cfg.Storage.User, cfg.Storage.Password, cfg.Storage.Name, cfg.Storage.Host)
+	var queryObserver postgresql.QueryObserver
+	var metricsRegistry *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		metricsRegistry = metrics.New()
+		queryObserver = metricsRegistry.ObserveQuery
+		if err := metricsRegistry.RegisterDB(db, cfg.Storage.Name); err != nil {
+			log.Error("failed to register database metrics", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	storage := postgresql.NewStorage(db, queryObserver)
+
+	if len(os.Args) > 1 && os.Args[1] == cmdAPIKey {
+		if err := runAPIKey(ctx, os.Stdout, storage, os.Args[2:]); err != nil {
+			log.Error("apikey failed", sl.Err(err))
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	apiConn := nasa_api.NewNasaApiConnect(cfg.NasaApi.Host, cfg.NasaApi.Token, cfg.NasaApi.Timeout, backoff.Policy{
+		Initial:     cfg.NasaApi.Retry.InitialDelay,
+		Max:         cfg.NasaApi.Retry.MaxDelay,
+		Jitter:      cfg.NasaApi.Retry.Jitter,
+		MaxAttempts: cfg.NasaApi.Retry.MaxAttempts,
+	})
+	if metricsRegistry != nil {
+		if err := metricsRegistry.RegisterRateLimit(apiConn); err != nil {
+			log.Error("failed to register rate limit metrics", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	nasaProvider := nasa_apod.New(apiConn)
+
+	var archiver apod_worker.MediaArchiver
+	var blobs *filesystem.Store
+	if cfg.MediaArchive.Enabled {
+		for _, format := range cfg.MediaArchive.DerivativeFormats {
+			if err := image_pipeline.CheckFormat(format); err != nil {
+				log.Error("invalid derivative format", sl.Err(err))
+				os.Exit(1)
+			}
+		}
+		for _, width := range cfg.MediaArchive.DerivativeWidths {
+			if width <= 0 {
+				log.Error("invalid derivative width", slog.Int("width", width))
+				os.Exit(1)
+			}
+		}
+
+		blobs, err = filesystem.NewStore(cfg.MediaArchive.Path)
+		if err != nil {
+			log.Error("failed to create media archive", sl.Err(err))
+			os.Exit(1)
+		}
+
+		archiver = media_archiver.NewArchiver(blobs, storage, log, cfg.MediaArchive.DownloadTimeout, cfg.MediaArchive.MaxDownloadSize, media_archiver.Derivatives{
+			Widths:    cfg.MediaArchive.DerivativeWidths,
+			Formats:   cfg.MediaArchive.DerivativeFormats,
+			MaxPixels: cfg.MediaArchive.MaxPixels,
+		})
+	}
+
+	sources := []source{{
+		provider: nasaProvider,
+		cron:     cfg.APODWorker.Schedule.Cron,
+		dailyAt:  cfg.APODWorker.Schedule.DailyAt,
+		lookback: apod_worker.Lookback{
+			GapDays:        cfg.APODWorker.GapLookbackDays,
+			CorrectionDays: cfg.APODWorker.CorrectionLookbackDays,
+		},
+	}}
+	if p := cfg.Providers.Bing; p.Enabled {
+		sources = append(sources, source{bing.New(p.Host, p.Market, cfg.Providers.Timeout), p.Cron, p.DailyAt, apod_worker.Lookback{GapDays: p.GapLookbackDays}})
+	}
+	if p := cfg.Providers.Wikimedia; p.Enabled {
+		sources = append(sources, source{wikimedia.New(p.Host, p.Language, cfg.Providers.Timeout), p.Cron, p.DailyAt, apod_worker.Lookback{GapDays: p.GapLookbackDays}})
+	}
+	if p := cfg.Providers.ESAHubble; p.Enabled {
+		sources = append(sources, source{esa_hubble.New(p.Host, cfg.Providers.Timeout), p.Cron, p.DailyAt, apod_worker.Lookback{GapDays: p.GapLookbackDays}})
+	}
+
+	readCache, err := newReadCache(cfg.ReadCache, storage, log)
 	if err != nil {
-		log.Error("failed to create storage", sl.Err(err))
+		log.Error("failed to create read cache", sl.Err(err))
 		os.Exit(1)
 	}
+	// A nil *cached.Storage must not become a non-nil interface value.
+	var invalidator apod_worker.CacheInvalidator
+	var journalInvalidator apod_backfill.JournalInvalidator
+	var reader cached.Reader = storage
+	if readCache != nil {
+		invalidator, journalInvalidator, reader = readCache, readCache, readCache
+	}
 
-	apiConn := nasa_api.NewNasaApiConnect(cfg.NasaApi.Host, cfg.NasaApi.Token)
+	if len(os.Args) > 1 && os.Args[1] == cmdBackfill {
+		if err := runBackfill(ctx, log, nasaProvider, storage, archiver, journalInvalidator, os.Args[2:]); err != nil {
+			log.Error("backfill failed", sl.Err(err))
+			os.Exit(1)
+		}
 
-	apodWorker := apod_worker.NewAPODWorker(apiConn, storage, log)
-	go apodWorker.Run()
+		return
+	}
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.Add("database", health.Database(db))
+	checker.Add("migrations", health.Migrations(migrator, db, migrator.Latest()))
+	checker.Add("latest_apod", health.LatestEntry(storage, stellar_journal_models.SourceNASAAPOD, cfg.Health.LatestAPODMaxAgeDays, clock.System))
+
+	sourceWorkers := apod_worker.Workers{}
+	var workers sync.WaitGroup
+	for _, src := range sources {
+		sched, err := newScheduler(src, cfg.APODWorker.Schedule, log)
+		if err != nil {
+			log.Error("invalid worker schedule", slog.String("source", src.provider.Source()), sl.Err(err))
+			os.Exit(1)
+		}
+
+		worker := apod_worker.NewAPODWorker(src.provider, storage, archiver, invalidator, sched, log, src.lookback)
+		sourceWorkers[src.provider.Source()] = worker
+		// Only NASA APOD, the source the journal is built on, affects readiness.
+		if src.provider.Source() == stellar_journal_models.SourceNASAAPOD {
+			checker.Add("worker", health.Worker(worker, cfg.Health.WorkerMaxAge, clock.System))
+		}
+		if metricsRegistry != nil {
+			metricsRegistry.RegisterWorker(src.provider.Source(), worker)
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker.Run(ctx)
+		}()
+	}
+	backfills := apod_backfill.NewRunner(ctx, apod_backfill.NewBackfiller(nasaProvider, storage, archiver, log, cfg.Admin.BackfillChunkDays), journalInvalidator, clock.System, log)
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		backfills.Wait()
+		close(workersDone)
+	}()
+
+	spec, err := api.Load()
+	if err != nil {
+		log.Error("failed to load OpenAPI spec", sl.Err(err))
+		os.Exit(1)
+	}
+	specJSON, err := spec.MarshalJSON()
+	if err != nil {
+		log.Error("failed to encode OpenAPI spec", sl.Err(err))
+		os.Exit(1)
+	}
 
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(mwTracing.New())
 	router.Use(mwLg.New(log))
+	if metricsRegistry != nil {
+		router.Use(mwMetrics.New(metricsRegistry))
+	}
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
-	router.Route("/journal", func(r chi.Router) {
-		r.Get("/", all.New(log, storage))
-		r.Get("/{date}", by_date.New(log, storage))
-	})
+	if metricsRegistry != nil {
+		router.Handle("/metrics", metricsRegistry.Handler())
+	}
+	// URLFormat strips the extension, so this serves /openapi.json.
+	router.Get("/openapi", docs.Spec(specJSON))
+	router.Get("/docs", docs.SwaggerUI("/openapi.json"))
 
-	log.Info("starting server", slog.String("address", cfg.HttpServer.Host))
+	limitIP, limitKey, err := newRateLimit(cfg.RateLimit, log)
+	if err != nil {
+		log.Error("failed to create rate limiter", sl.Err(err))
+		os.Exit(1)
+	}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	routes := httpRouter.Options{
+		Log:      log,
+		Checker:  checker,
+		Keys:     storage,
+		Clock:    clock.System,
+		Auth:     cfg.Auth.Enabled,
+		LimitIP:  limitIP,
+		LimitKey: limitKey,
+		Journal: httpRouter.Journal{
+			Pages:     reader,
+			Entries:   reader,
+			Searcher:  storage,
+			Revisions: storage,
+			Media:     storage,
+			// Responses fetched with a key must not be served to clients
+			// without one.
+			CachePolicy: http_cache.Policy{
+				MaxAge:       cfg.HttpServer.Cache.MaxAge,
+				RecentMaxAge: cfg.HttpServer.Cache.RecentMaxAge,
+				RecentDays:   cfg.APODWorker.CorrectionLookbackDays,
+				Private:      cfg.Auth.Enabled,
+			},
+		},
+	}
+	// A nil *filesystem.Store must not become a non-nil interface value.
+	if blobs != nil {
+		routes.Journal.Blobs = blobs
+	}
+	if cfg.Admin.Enabled {
+		routes.Admin = &httpRouter.Admin{
+			Fetcher:      sourceWorkers,
+			FetchTimeout: cfg.Admin.FetchTimeout,
+			Deleter:      storage,
+			Cache:        invalidator,
+			Backfills:    backfills,
+			Workers:      sourceWorkers,
+		}
+	}
+	httpRouter.Mount(router, routes)
+
+	log.Info("starting server", slog.String("address", cfg.HttpServer.Host))
 
 	srv := &http.Server{
 		Addr:         cfg.HttpServer.Host,
@@ -114,13 +376,13 @@ func main() {
 
 	log.Info("server started")
 
-	<-done
+	<-ctx.Done()
 	log.Info("stopping server")
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.CtxTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.CtxTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Error("failed to stop server", sl.Err(err))
 
 		return
@@ -128,6 +390,36 @@ func main() {
 
 	log.Info("server stopped")
 
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		log.Error("workers did not stop in time")
+	}
+}
+
+// source is a picture provider with the settings of its worker.
+type source struct {
+	provider apod_worker.Provider
+	cron     string
+	dailyAt  string
+	lookback apod_worker.Lookback
+}
+
+// newScheduler creates the scheduler of the source's worker. Everything but the
+// run times comes from the APOD worker schedule.
+func newScheduler(src source, schedCfg config.Schedule, log *slog.Logger) (*scheduler.Scheduler, error) {
+	schedule, err := scheduler.ParseSchedule(src.cron, src.dailyAt, schedCfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduler.New(schedule, backoff.Policy{
+		Initial:     schedCfg.Retry.InitialDelay,
+		Max:         schedCfg.Retry.MaxDelay,
+		Multiplier:  schedCfg.Retry.Multiplier,
+		Jitter:      schedCfg.Retry.Jitter,
+		MaxAttempts: schedCfg.Retry.MaxAttempts,
+	}, schedCfg.RunOnStart, clock.System, log.With(slog.String("source", src.provider.Source()))), nil
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -154,3 +446,101 @@ func setupLogger(env string) *slog.Logger {
 
 	return log
 }
+
+// newReadCache returns the cache of storage reads selected by cfg, or nil when
+// caching is disabled.
+func newReadCache(cfg config.ReadCache, next cached.Reader, log *slog.Logger) (*cached.Storage, error) {
+	switch cfg.Backend {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return cached.New(next, lru.NewStore(cfg.MaxEntries, clock.System), cfg.TTL, log), nil
+	case "resp":
+		store := resp.NewStore(cfg.RESP.Addr, resp.Options{
+			Password: cfg.RESP.Password,
+			DB:       cfg.RESP.DB,
+			Timeout:  cfg.RESP.Timeout,
+			PoolSize: cfg.RESP.PoolSize,
+		})
+		// The server may come up later; reads go to the database until it does.
+		if err := store.Ping(context.Background()); err != nil {
+			log.Warn("read cache is unreachable", slog.String("addr", cfg.RESP.Addr), sl.Err(err))
+		}
+
+		return cached.New(next, store, cfg.TTL, log), nil
+	}
+
+	return nil, fmt.Errorf("unknown read cache backend %q, expected none, memory or resp", cfg.Backend)
+}
+
+const (
+	keyByIP     = "ip"
+	keyByAPIKey = "api_key"
+)
+
+// newRateLimit returns the rate limiting middleware selected by cfg, or nil when
+// rate limiting is disabled.
+func newRateLimit(cfg config.RateLimit, log *slog.Logger) (byIP, byKey func(http.Handler) http.Handler, err error) {
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+	if cfg.Requests <= 0 || cfg.Window <= 0 {
+		return nil, nil, fmt.Errorf("rate limit requests and window must be positive")
+	}
+	if cfg.IPRequests < 0 {
+		return nil, nil, fmt.Errorf("rate limit ip_requests must not be negative")
+	}
+	if cfg.KeyBy != keyByIP && cfg.KeyBy != keyByAPIKey {
+		return nil, nil, fmt.Errorf("unknown rate limit key %q, expected ip or api_key", cfg.KeyBy)
+	}
+
+	trusted, err := mwRateLimit.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ipPolicy := rate_limit.Policy{Limit: cfg.Requests, Window: cfg.Window}
+	if cfg.IPRequests > 0 {
+		ipPolicy.Limit = cfg.IPRequests
+	}
+	newLimiter, err := limiterFactory(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	byIP = mwRateLimit.New(log, newLimiter(ipPolicy), ipPolicy, mwRateLimit.ByIP(trusted))
+
+	if cfg.KeyBy == keyByAPIKey {
+		// Requests without a key are limited by IP only.
+		keyPolicy := rate_limit.Policy{Limit: cfg.Requests, Window: cfg.Window}
+		byKey = mwRateLimit.New(log, newLimiter(keyPolicy), keyPolicy, mwRateLimit.ByAPIKey(nil))
+	}
+
+	return byIP, byKey, nil
+}
+
+// limiterFactory returns a constructor of limiters on the configured backend,
+// which share its connections.
+func limiterFactory(cfg config.RateLimit, log *slog.Logger) (func(rate_limit.Policy) mwRateLimit.Limiter, error) {
+	switch cfg.Backend {
+	case "memory":
+		return func(policy rate_limit.Policy) mwRateLimit.Limiter {
+			return memory.NewLimiter(policy, clock.System)
+		}, nil
+	case "resp":
+		store := resp.NewStore(cfg.RESP.Addr, resp.Options{
+			Password: cfg.RESP.Password,
+			DB:       cfg.RESP.DB,
+			Timeout:  cfg.RESP.Timeout,
+			PoolSize: cfg.RESP.PoolSize,
+		})
+		// Requests are let through while the server is unreachable.
+		if err := store.Ping(context.Background()); err != nil {
+			log.Warn("rate limiter is unreachable", slog.String("addr", cfg.RESP.Addr), sl.Err(err))
+		}
+		return func(policy rate_limit.Policy) mwRateLimit.Limiter {
+			return rlresp.NewLimiter(store, policy)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q, expected memory or resp", cfg.Backend)
+	}
+}
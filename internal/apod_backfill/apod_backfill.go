@@ -0,0 +1,218 @@
+package apod_backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+)
+
+const DefaultChunkDays = 30
+
+// RangeProvider fetches the pictures of a source published within a range of
+// days at once.
+type RangeProvider interface {
+	Source() string
+	GetRange(ctx context.Context, startDate, endDate apod_date.Date) ([]stellar_journal_models.APOD, error)
+}
+
+type Storage interface {
+	SaveAPOD(ctx context.Context, apod *stellar_journal_models.APOD) error
+	GetAPODDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error)
+	GetNoPictureDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error)
+	SaveNoPictureDates(ctx context.Context, source string, dates []apod_date.Date) error
+}
+
+// MediaArchiver downloads the images of a saved APOD.
+type MediaArchiver interface {
+	Archive(ctx context.Context, apod *stellar_journal_models.APOD) error
+}
+
+// Stats describes the outcome of a backfill run.
+type Stats struct {
+	Chunks        int
+	SkippedChunks int
+	Inserted      int
+	Skipped       int
+	NoPicture     int
+}
+
+type Backfiller struct {
+	provider  RangeProvider
+	storage   Storage
+	archiver  MediaArchiver
+	logger    *slog.Logger
+	chunkDays int
+}
+
+// NewBackfiller creates a backfiller that archives the images of the APODs it
+// saves unless archiver is nil.
+func NewBackfiller(provider RangeProvider, storage Storage, archiver MediaArchiver, logger *slog.Logger, chunkDays int) *Backfiller {
+	if chunkDays <= 0 {
+		chunkDays = DefaultChunkDays
+	}
+
+	return &Backfiller{
+		provider:  provider,
+		storage:   storage,
+		archiver:  archiver,
+		logger:    logger,
+		chunkDays: chunkDays,
+	}
+}
+
+// Run fetches every APOD between from and to (inclusive) in chunks and saves the
+// ones missing from the storage. Days before the latest day the provider
+// returned a picture for in a chunk, but that it returned none for, are
+// recorded as having no picture. Chunks whose days are all stored or recorded
+// are skipped without calling the provider, so an interrupted run can simply
+// be restarted. Cancelling ctx stops the run before the next chunk.
+func (b *Backfiller) Run(ctx context.Context, from, to apod_date.Date) (*Stats, error) {
+	const op = "internal/apod_backfill.Run"
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("%s: end date %s is before start date %s", op, to, from)
+	}
+
+	totalDays := from.DaysUntil(to) + 1
+	totalChunks := (totalDays + b.chunkDays - 1) / b.chunkDays
+
+	stats := &Stats{}
+	for start := from; !start.After(to); start = start.AddDays(b.chunkDays) {
+		if err := ctx.Err(); err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		end := start.AddDays(b.chunkDays - 1)
+		if end.After(to) {
+			end = to
+		}
+
+		if err := b.fillChunk(ctx, start, end, stats); err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.Chunks++
+
+		b.logger.Info(
+			"backfill progress",
+			slog.String("from", start.String()),
+			slog.String("to", end.String()),
+			slog.Int("chunk", stats.Chunks),
+			slog.Int("chunks", totalChunks),
+			slog.Int("inserted", stats.Inserted),
+			slog.Int("skipped", stats.Skipped),
+			slog.Int("no_picture", stats.NoPicture),
+			slog.String("progress", fmt.Sprintf("%.1f%%", float64(stats.Chunks)*100/float64(totalChunks))),
+		)
+	}
+
+	return stats, nil
+}
+
+func (b *Backfiller) fillChunk(ctx context.Context, start, end apod_date.Date, stats *Stats) error {
+	existing, err := b.storage.GetAPODDates(ctx, b.provider.Source(), start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get stored dates for %s..%s: %w", start, end, err)
+	}
+
+	days := start.DaysUntil(end) + 1
+	if len(existing) == days {
+		stats.SkippedChunks++
+		stats.Skipped += len(existing)
+		return nil
+	}
+
+	noPicture, err := b.storage.GetNoPictureDates(ctx, b.provider.Source(), start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get dates without a picture for %s..%s: %w", start, end, err)
+	}
+
+	if len(existing)+len(noPicture) == days {
+		stats.SkippedChunks++
+		stats.Skipped += len(existing)
+		stats.NoPicture += len(noPicture)
+		return nil
+	}
+
+	stored := make(map[apod_date.Date]struct{}, len(existing)+len(noPicture))
+	for _, date := range existing {
+		stored[date] = struct{}{}
+	}
+
+	apods, err := b.provider.GetRange(ctx, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get APODs for %s..%s: %w", start, end, err)
+	}
+
+	var latest apod_date.Date
+	for i := range apods {
+		apod := &apods[i]
+		if apod.Date.After(latest) {
+			latest = apod.Date
+		}
+		if _, ok := stored[apod.Date]; ok {
+			stats.Skipped++
+			continue
+		}
+		stored[apod.Date] = struct{}{}
+
+		err := b.storage.SaveAPOD(ctx, apod)
+		if errors.Is(err, storage.ErrAPODExists) {
+			stats.Skipped++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save APOD for %s: %w", apod.Date, err)
+		}
+
+		stats.Inserted++
+		b.archive(ctx, apod)
+	}
+
+	// The provider returns every picture published in the range, so days
+	// before the latest one it returned will never have one. Later days may
+	// not be published yet.
+	recorded := make(map[apod_date.Date]struct{}, len(noPicture))
+	for _, date := range noPicture {
+		recorded[date] = struct{}{}
+	}
+	var missing []apod_date.Date
+	for date := start; date.Before(latest); date = date.AddDays(1) {
+		if _, ok := stored[date]; ok {
+			continue
+		}
+		stats.NoPicture++
+		if _, ok := recorded[date]; !ok {
+			missing = append(missing, date)
+		}
+	}
+	if len(missing) > 0 {
+		if err := b.storage.SaveNoPictureDates(ctx, b.provider.Source(), missing); err != nil {
+			return fmt.Errorf("failed to record dates without a picture for %s..%s: %w", start, end, err)
+		}
+		b.logger.Info(
+			"provider has no picture for some days",
+			slog.String("from", start.String()),
+			slog.String("to", end.String()),
+			slog.Int("days", len(missing)),
+		)
+	}
+
+	return nil
+}
+
+// archive downloads the images of a saved APOD. A failure is logged and does
+// not stop the backfill; the images can be archived by fetching the day again.
+func (b *Backfiller) archive(ctx context.Context, apod *stellar_journal_models.APOD) {
+	if b.archiver == nil {
+		return
+	}
+
+	if err := b.archiver.Archive(ctx, apod); err != nil {
+		b.logger.Error("failed to archive APOD media", slog.String("date", apod.Date.String()), sl.Err(err))
+	}
+}
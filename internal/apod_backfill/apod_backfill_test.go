@@ -0,0 +1,187 @@
+package apod_backfill_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"stellar_journal/internal/apod_backfill"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"testing"
+)
+
+type MockRangeProvider struct {
+	mock.Mock
+}
+
+func (m *MockRangeProvider) Source() string {
+	return stellar_journal_models.SourceNASAAPOD
+}
+
+func (m *MockRangeProvider) GetRange(ctx context.Context, startDate, endDate apod_date.Date) ([]stellar_journal_models.APOD, error) {
+	args := m.Called(ctx, startDate, endDate)
+	apods, _ := args.Get(0).([]stellar_journal_models.APOD)
+	return apods, args.Error(1)
+}
+
+type MockStorage struct {
+	mock.Mock
+}
+
+func (m *MockStorage) SaveAPOD(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	args := m.Called(ctx, apod)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetAPODDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	args := m.Called(ctx, source, startDate, endDate)
+	dates, _ := args.Get(0).([]apod_date.Date)
+	return dates, args.Error(1)
+}
+
+func (m *MockStorage) GetNoPictureDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	args := m.Called(ctx, source, startDate, endDate)
+	dates, _ := args.Get(0).([]apod_date.Date)
+	return dates, args.Error(1)
+}
+
+func (m *MockStorage) SaveNoPictureDates(ctx context.Context, source string, dates []apod_date.Date) error {
+	args := m.Called(ctx, source, dates)
+	return args.Error(0)
+}
+
+type MockArchiver struct {
+	mock.Mock
+}
+
+func (m *MockArchiver) Archive(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	args := m.Called(ctx, apod)
+	return args.Error(0)
+}
+
+func TestBackfiller_Run(t *testing.T) {
+	t.Run("FillsMissingDays", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03")).Return([]apod_date.Date{apod_date.MustParse("2024-01-02")}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03")).Return([]apod_date.Date{}, nil).Once()
+		api.On("GetRange", mock.Anything, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03")).Return([]stellar_journal_models.APOD{
+			{Date: apod_date.MustParse("2024-01-01")}, {Date: apod_date.MustParse("2024-01-02")}, {Date: apod_date.MustParse("2024-01-03")},
+		}, nil).Once()
+		st.On("SaveAPOD", mock.Anything, &stellar_journal_models.APOD{Date: apod_date.MustParse("2024-01-01")}).Return(nil).Once()
+		st.On("SaveAPOD", mock.Anything, &stellar_journal_models.APOD{Date: apod_date.MustParse("2024-01-03")}).Return(storage.ErrAPODExists).Once()
+		archiver := new(MockArchiver)
+		archiver.On("Archive", mock.Anything, &stellar_journal_models.APOD{Date: apod_date.MustParse("2024-01-01")}).Return(errors.New("download failed")).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, archiver, slogdiscard.NewDiscardLogger(), 3)
+		stats, err := b.Run(context.Background(), apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03"))
+		require.NoError(t, err)
+
+		require.Equal(t, 1, stats.Chunks)
+		require.Equal(t, 1, stats.Inserted)
+		require.Equal(t, 2, stats.Skipped)
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+		archiver.AssertExpectations(t)
+	})
+
+	t.Run("RecordsDaysWithoutPicture", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+		from, to := apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-05")
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{apod_date.MustParse("2024-01-02")}, nil).Once()
+		api.On("GetRange", mock.Anything, from, to).Return([]stellar_journal_models.APOD{
+			{Date: apod_date.MustParse("2024-01-01")}, {Date: apod_date.MustParse("2024-01-04")},
+		}, nil).Once()
+		st.On("SaveAPOD", mock.Anything, mock.Anything).Return(nil).Twice()
+		st.On("SaveNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, []apod_date.Date{apod_date.MustParse("2024-01-03")}).Return(nil).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 5)
+		stats, err := b.Run(context.Background(), from, to)
+		require.NoError(t, err)
+
+		require.Equal(t, 2, stats.Inserted)
+		require.Equal(t, 2, stats.NoPicture, "the last day may not be published yet")
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+
+	t.Run("SkipsChunksCompleteWithDaysWithoutPicture", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+		from, to := apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03")
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{from, to}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{apod_date.MustParse("2024-01-02")}, nil).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 3)
+		stats, err := b.Run(context.Background(), from, to)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, stats.SkippedChunks)
+		require.Equal(t, 1, stats.NoPicture)
+		api.AssertNotCalled(t, "GetRange", mock.Anything, mock.Anything, mock.Anything)
+		st.AssertExpectations(t)
+	})
+
+	t.Run("SkipsCompleteChunks", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")).Return([]apod_date.Date{apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")}, nil).Once()
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-03"), apod_date.MustParse("2024-01-03")).Return([]apod_date.Date{}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-03"), apod_date.MustParse("2024-01-03")).Return([]apod_date.Date{}, nil).Once()
+		api.On("GetRange", mock.Anything, apod_date.MustParse("2024-01-03"), apod_date.MustParse("2024-01-03")).Return([]stellar_journal_models.APOD{{Date: apod_date.MustParse("2024-01-03")}}, nil).Once()
+		st.On("SaveAPOD", mock.Anything, mock.Anything).Return(nil).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 2)
+		stats, err := b.Run(context.Background(), apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-03"))
+		require.NoError(t, err)
+
+		require.Equal(t, 2, stats.Chunks)
+		require.Equal(t, 1, stats.SkippedChunks)
+		require.Equal(t, 1, stats.Inserted)
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+
+	t.Run("StopsOnAPIError", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")).Return([]apod_date.Date{}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")).Return([]apod_date.Date{}, nil).Once()
+		api.On("GetRange", mock.Anything, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")).Return(nil, errors.New("error")).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 2)
+		stats, err := b.Run(context.Background(), apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-04"))
+		require.Error(t, err)
+
+		require.Equal(t, 0, stats.Chunks)
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+	t.Run("StopsWhenCancelled", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")).
+			Run(func(mock.Arguments) { cancel() }).
+			Return([]apod_date.Date{apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")}, nil).Once()
+
+		b := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 2)
+		stats, err := b.Run(ctx, apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-04"))
+		require.ErrorIs(t, err, context.Canceled)
+
+		require.Equal(t, 1, stats.Chunks)
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,133 @@
+package apod_backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/logger/sl"
+	"sync"
+	"time"
+)
+
+var ErrRunning = errors.New("a backfill is running")
+
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is a backfill started by a Runner. Stats are known once it finished.
+type Job struct {
+	From       apod_date.Date
+	To         apod_date.Date
+	Status     string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Stats      Stats
+	Error      string
+}
+
+// JournalInvalidator drops the cached journal pages that a backfill makes
+// stale.
+type JournalInvalidator interface {
+	InvalidateJournal(ctx context.Context) error
+}
+
+// Runner runs one backfill at a time in the background.
+type Runner struct {
+	ctx        context.Context
+	backfiller *Backfiller
+	cache      JournalInvalidator
+	clock      clock.Clock
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	job     *Job
+	running sync.WaitGroup
+}
+
+// NewRunner creates a runner whose backfills stop when ctx is cancelled. The
+// journal pages are invalidated after every backfill unless cache is nil.
+func NewRunner(ctx context.Context, backfiller *Backfiller, cache JournalInvalidator, clk clock.Clock, logger *slog.Logger) *Runner {
+	return &Runner{
+		ctx:        ctx,
+		backfiller: backfiller,
+		cache:      cache,
+		clock:      clk,
+		logger:     logger,
+	}
+}
+
+// Start starts a backfill of the days between from and to (inclusive) and
+// returns its job. It fails with ErrRunning while another backfill runs.
+func (r *Runner) Start(from, to apod_date.Date) (Job, error) {
+	const op = "internal/apod_backfill.Runner.Start"
+
+	if to.Before(from) {
+		return Job{}, fmt.Errorf("%s: end date %s is before start date %s", op, to, from)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.job != nil && r.job.Status == JobRunning {
+		return Job{}, fmt.Errorf("%s: %w", op, ErrRunning)
+	}
+
+	r.job = &Job{From: from, To: to, Status: JobRunning, StartedAt: r.clock.Now()}
+	r.running.Add(1)
+	go r.run(from, to)
+
+	return *r.job, nil
+}
+
+// Job returns the running backfill, or the last one if none runs. It reports
+// false if no backfill was started.
+func (r *Runner) Job() (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.job == nil {
+		return Job{}, false
+	}
+
+	return *r.job, true
+}
+
+// Wait waits until the running backfill, if any, finished.
+func (r *Runner) Wait() {
+	r.running.Wait()
+}
+
+func (r *Runner) run(from, to apod_date.Date) {
+	defer r.running.Done()
+
+	r.logger.Info("starting backfill", slog.String("from", from.String()), slog.String("to", to.String()))
+
+	stats, err := r.backfiller.Run(r.ctx, from, to)
+	if err != nil {
+		r.logger.Error("backfill failed", sl.Err(err))
+	}
+	if stats != nil && stats.Inserted > 0 && r.cache != nil {
+		if err := r.cache.InvalidateJournal(context.WithoutCancel(r.ctx)); err != nil {
+			r.logger.Error("failed to invalidate cached journal pages", sl.Err(err))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.job.FinishedAt = r.clock.Now()
+	if stats != nil {
+		r.job.Stats = *stats
+	}
+	r.job.Status = JobSucceeded
+	if err != nil {
+		r.job.Status = JobFailed
+		r.job.Error = err.Error()
+	}
+}
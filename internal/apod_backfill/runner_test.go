@@ -0,0 +1,106 @@
+package apod_backfill_test
+
+import (
+	"context"
+	"errors"
+	"stellar_journal/internal/apod_backfill"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockJournalInvalidator struct {
+	mock.Mock
+}
+
+func (m *MockJournalInvalidator) InvalidateJournal(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestRunner(t *testing.T) {
+	from, to := apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-02")
+	now := time.Date(2024, time.January, 5, 12, 0, 0, 0, time.UTC)
+
+	t.Run("RunsOneBackfillAtATime", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+		cache := new(MockJournalInvalidator)
+		release := make(chan struct{})
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{}, nil).Once()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{}, nil).Once()
+		api.On("GetRange", mock.Anything, from, to).
+			Run(func(mock.Arguments) { <-release }).
+			Return([]stellar_journal_models.APOD{{Date: from}, {Date: to}}, nil).Once()
+		st.On("SaveAPOD", mock.Anything, mock.Anything).Return(nil).Twice()
+		cache.On("InvalidateJournal", mock.Anything).Return(nil).Once()
+
+		backfiller := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 30)
+		runner := apod_backfill.NewRunner(context.Background(), backfiller, cache, fakeclock.New(now), slogdiscard.NewDiscardLogger())
+
+		_, ok := runner.Job()
+		require.False(t, ok)
+
+		job, err := runner.Start(from, to)
+		require.NoError(t, err)
+		require.Equal(t, apod_backfill.JobRunning, job.Status)
+		require.True(t, now.Equal(job.StartedAt))
+
+		_, err = runner.Start(from, to)
+		require.ErrorIs(t, err, apod_backfill.ErrRunning)
+
+		close(release)
+		runner.Wait()
+
+		job, ok = runner.Job()
+		require.True(t, ok)
+		require.Equal(t, apod_backfill.JobSucceeded, job.Status)
+		require.Equal(t, 2, job.Stats.Inserted)
+		require.Empty(t, job.Error)
+		api.AssertExpectations(t)
+		st.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("ReportsFailures", func(t *testing.T) {
+		api := new(MockRangeProvider)
+		st := new(MockStorage)
+
+		st.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{}, nil).Twice()
+		st.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, from, to).Return([]apod_date.Date{}, nil).Twice()
+		api.On("GetRange", mock.Anything, from, to).Return(nil, errors.New("rate limited")).Twice()
+
+		backfiller := apod_backfill.NewBackfiller(api, st, nil, slogdiscard.NewDiscardLogger(), 30)
+		runner := apod_backfill.NewRunner(context.Background(), backfiller, nil, fakeclock.New(now), slogdiscard.NewDiscardLogger())
+
+		_, err := runner.Start(from, to)
+		require.NoError(t, err)
+		runner.Wait()
+
+		job, _ := runner.Job()
+		require.Equal(t, apod_backfill.JobFailed, job.Status)
+		require.Contains(t, job.Error, "rate limited")
+
+		_, err = runner.Start(from, to)
+		require.NoError(t, err, "a failed backfill can be started again")
+		runner.Wait()
+		api.AssertExpectations(t)
+	})
+
+	t.Run("RejectsInvertedRange", func(t *testing.T) {
+		backfiller := apod_backfill.NewBackfiller(new(MockRangeProvider), new(MockStorage), nil, slogdiscard.NewDiscardLogger(), 30)
+		runner := apod_backfill.NewRunner(context.Background(), backfiller, nil, fakeclock.New(now), slogdiscard.NewDiscardLogger())
+
+		_, err := runner.Start(to, from)
+		require.Error(t, err)
+		_, ok := runner.Job()
+		require.False(t, ok)
+	})
+}
@@ -1,64 +1,439 @@
 package apod_worker
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"stellar_journal/internal/lib/apod_date"
 	"stellar_journal/internal/lib/logger/sl"
-	"stellar_journal/internal/models/nasa_api_models"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/scheduler"
 	"stellar_journal/internal/storage"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type APODAPI interface {
-	GetAPOD() (*nasa_api_models.APODResp, error)
+var tracer = otel.Tracer("stellar_journal/internal/apod_worker")
+
+var ErrUnknownSource = errors.New("no worker for the source")
+
+// Provider fetches the picture of the day of one source.
+type Provider interface {
+	Source() string
+	GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error)
 }
 
 type Storage interface {
-	SaveAPOD(apod *nasa_api_models.APODResp) error
+	SaveAPOD(ctx context.Context, apod *stellar_journal_models.APOD) error
+	UpsertAPOD(ctx context.Context, apod *stellar_journal_models.APOD) (storage.Upsert, error)
+	GetAPODDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error)
+	GetNoPictureDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error)
+	SaveNoPictureDates(ctx context.Context, source string, dates []apod_date.Date) error
+}
+
+// MediaArchiver downloads the images of a saved APOD.
+type MediaArchiver interface {
+	Archive(ctx context.Context, apod *stellar_journal_models.APOD) error
+}
+
+// CacheInvalidator drops cached reads that a saved APOD makes stale.
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context, apod *stellar_journal_models.APOD) error
+}
+
+// Scheduler runs the worker's job at the configured times. Next returns when
+// it runs the job next, or the zero time while the job runs.
+type Scheduler interface {
+	Run(ctx context.Context, job scheduler.Job)
+	Next() time.Time
 }
 
 type APODWorkerImpl struct {
-	nasaApi APODAPI
-	storage Storage
-	logger  *slog.Logger
+	provider  Provider
+	storage   Storage
+	archiver  MediaArchiver
+	cache     CacheInvalidator
+	scheduler Scheduler
+	logger    *slog.Logger
+	lookback  Lookback
+	// lastRun and lastSuccess are the UnixNano times the last run and the last
+	// run that succeeded started.
+	lastRun     atomic.Int64
+	lastSuccess atomic.Int64
+	lastError   atomic.Pointer[string]
+	successes   atomic.Uint64
+	failures    atomic.Uint64
+}
+
+// Stats counts the runs of a worker. Retries of a failed run count as runs.
+// LastError is the error of the last run, empty if it succeeded, and NextRun the
+// time of the next run or retry, zero while a run is in progress.
+type Stats struct {
+	Successes   uint64
+	Failures    uint64
+	LastRun     time.Time
+	LastSuccess time.Time
+	LastError   string
+	NextRun     time.Time
+}
+
+// Lookback selects the past days a run checks besides today. Days missing from
+// the storage within the last GapDays days are fetched, and stored days within
+// the last CorrectionDays days are fetched again so that corrections the source
+// made after publication are picked up. Non-positive values disable either.
+type Lookback struct {
+	GapDays        int
+	CorrectionDays int
 }
 
-func NewAPODWorker(nasaApi APODAPI, storage Storage, logger *slog.Logger) *APODWorkerImpl {
+// NewAPODWorker creates a worker that fetches the picture of the provider
+// whenever the scheduler fires, checking the past days selected by lookback on
+// every run. Images of saved APODs are archived unless archiver is nil, and
+// cached reads are invalidated after every save and archive unless cache is nil.
+func NewAPODWorker(provider Provider, storage Storage, archiver MediaArchiver, cache CacheInvalidator, scheduler Scheduler, logger *slog.Logger, lookback Lookback) *APODWorkerImpl {
 	return &APODWorkerImpl{
-		nasaApi: nasaApi,
-		storage: storage,
-		logger:  logger,
+		provider:  provider,
+		storage:   storage,
+		archiver:  archiver,
+		cache:     cache,
+		scheduler: scheduler,
+		logger:    logger.With(slog.String("source", provider.Source())),
+		lookback:  lookback,
 	}
 }
 
-func (w *APODWorkerImpl) Run() {
-	errCount := 0
-	for {
-		apod, err := w.nasaApi.GetAPOD()
-		if err != nil {
-			w.logger.Error("Failed to get APOD", sl.Err(err))
-			time.Sleep(1 * time.Hour)
+// Run fetches the APOD on schedule until ctx is cancelled. A save that is in
+// progress when ctx is cancelled is completed before Run returns.
+func (w *APODWorkerImpl) Run(ctx context.Context) {
+	w.scheduler.Run(ctx, func(ctx context.Context, now time.Time) error {
+		// The run is the root of the trace of its provider and storage calls.
+		ctx, span := tracer.Start(ctx, "apod_worker.run", trace.WithAttributes(attribute.String("source", w.provider.Source())))
+		defer span.End()
+
+		w.lastRun.Store(now.UnixNano())
+		if err := w.fetch(ctx, now); err != nil {
+			msg := err.Error()
+			w.lastError.Store(&msg)
+			w.failures.Add(1)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		w.lastError.Store(nil)
+		w.lastSuccess.Store(now.UnixNano())
+		w.successes.Add(1)
+
+		return nil
+	})
+	w.logger.Info("APOD worker stopped")
+}
+
+// LastSuccess returns when the last successful run started, or the zero time
+// if no run succeeded yet.
+func (w *APODWorkerImpl) LastSuccess() time.Time {
+	return unixNano(w.lastSuccess.Load())
+}
+
+func (w *APODWorkerImpl) Stats() Stats {
+	stats := Stats{
+		Successes:   w.successes.Load(),
+		Failures:    w.failures.Load(),
+		LastRun:     unixNano(w.lastRun.Load()),
+		LastSuccess: w.LastSuccess(),
+		NextRun:     w.scheduler.Next(),
+	}
+	if msg := w.lastError.Load(); msg != nil {
+		stats.LastError = *msg
+	}
+
+	return stats
+}
+
+func unixNano(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+// Fetch fetches and saves the picture of date right away, outside of the
+// schedule, and reports whether its entry was created. A stored entry is
+// overwritten when overwrite is set and its content differs; otherwise
+// storage.ErrAPODExists is returned.
+func (w *APODWorkerImpl) Fetch(ctx context.Context, date apod_date.Date, overwrite bool) (*stellar_journal_models.APOD, bool, error) {
+	const op = "internal/apod_worker.Fetch"
+
+	ctx, span := tracer.Start(ctx, "apod_worker.fetch", trace.WithAttributes(
+		attribute.String("source", w.provider.Source()),
+		attribute.String("date", date.String()),
+		attribute.Bool("overwrite", overwrite),
+	))
+	defer span.End()
+
+	apod, err := w.provider.GetByDate(ctx, date)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: failed to get APOD: %w", op, err)
+	}
+
+	created := true
+	if overwrite {
+		var result storage.Upsert
+		result, err = w.upsert(ctx, apod)
+		created = result == storage.UpsertCreated
+	} else {
+		err = w.save(ctx, apod)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: failed to save APOD: %w", op, err)
+	}
+
+	w.logger.Info("APOD fetched on demand", slog.String("date", apod.Date.String()), slog.Bool("created", created))
+	w.archive(ctx, apod)
+
+	return apod, created, nil
+}
+
+// fetch saves the picture of the day, or updates its entry when the source
+// corrected it since it was saved. It fails while the picture is not published
+// yet so that the scheduler retries later, unless the source rejected the
+// credentials or has no picture for the day.
+func (w *APODWorkerImpl) fetch(ctx context.Context, now time.Time) error {
+	const op = "internal/apod_worker.fetch"
+
+	today := apod_date.FromTime(now.In(apod_date.Location))
+	w.recheck(ctx, today)
+	w.fillGaps(ctx, today)
+
+	apod, err := w.provider.GetByDate(ctx, today)
+	if errors.Is(err, providers.ErrUnauthorized) {
+		return scheduler.Permanent(fmt.Errorf("%s: check the credentials of the source: %w", op, err))
+	}
+	if errors.Is(err, providers.ErrNoPicture) {
+		w.logger.Info("No picture for the day", slog.String("date", today.String()))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: failed to get APOD: %w", op, err)
+	}
+
+	result, err := w.upsert(ctx, apod)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save APOD: %w", op, err)
+	}
+
+	switch result {
+	case storage.UpsertUnchanged:
+		w.logger.Info("APOD already saved", slog.String("date", apod.Date.String()))
+		return nil
+	case storage.UpsertUpdated:
+		w.logger.Info("APOD updated with corrections", slog.String("date", apod.Date.String()))
+	default:
+		w.logger.Info("APOD saved successfully", slog.String("date", apod.Date.String()))
+	}
+	w.archive(ctx, apod)
+
+	return nil
+}
+
+// fillGaps fetches every day of the lookback window, up to yesterday, that is
+// missing from the storage.
+func (w *APODWorkerImpl) fillGaps(ctx context.Context, today apod_date.Date) {
+	start, end, stored, ok := w.storedDates(ctx, today, w.lookback.GapDays)
+	if !ok {
+		return
+	}
+
+	// Days the source has no picture for are not gaps, as in backfills.
+	noPicture, err := w.storage.GetNoPictureDates(ctx, w.provider.Source(), start, end)
+	if err != nil {
+		w.logger.Error("Failed to get dates without a picture", sl.Err(err))
+		return
+	}
+	for _, date := range noPicture {
+		stored[date] = struct{}{}
+	}
+
+	for date := start; !date.After(end) && ctx.Err() == nil; date = date.AddDays(1) {
+		if _, ok := stored[date]; ok {
 			continue
 		}
 
-		err = w.storage.SaveAPOD(apod)
-		if err == storage.ErrAPODExists {
-			errCount++
-			waitTime := 1 * time.Hour
-			if errCount >= 2 {
-				waitTime = 24 * time.Hour
+		apod, err := w.provider.GetByDate(ctx, date)
+		if errors.Is(err, providers.ErrRateLimited) || errors.Is(err, providers.ErrUnauthorized) {
+			w.logger.Error("Stopped filling gaps", slog.String("date", date.String()), sl.Err(err))
+			return
+		}
+		if errors.Is(err, providers.ErrNoPicture) {
+			if err := w.storage.SaveNoPictureDates(ctx, w.provider.Source(), []apod_date.Date{date}); err != nil {
+				w.logger.Error("Failed to save date without a picture", slog.String("date", date.String()), sl.Err(err))
 			}
-			w.logger.Info("APOD already exists, retrying after wait time")
-			time.Sleep(waitTime)
+			continue
+		}
+		if err != nil {
+			w.logger.Error("Failed to get missing APOD", slog.String("date", date.String()), sl.Err(err))
+			continue
+		}
+
+		err = w.save(ctx, apod)
+		if errors.Is(err, storage.ErrAPODExists) {
+			continue
+		}
+		if err != nil {
+			w.logger.Error("Failed to save missing APOD", slog.String("date", date.String()), sl.Err(err))
+			continue
+		}
+
+		w.logger.Info("APOD gap filled", slog.String("date", date.String()))
+		w.archive(ctx, apod)
+	}
+}
+
+// recheck fetches every stored day of the correction window, up to yesterday,
+// again and updates the entries the source corrected since they were saved.
+func (w *APODWorkerImpl) recheck(ctx context.Context, today apod_date.Date) {
+	start, end, stored, ok := w.storedDates(ctx, today, w.lookback.CorrectionDays)
+	if !ok {
+		return
+	}
+
+	for date := start; !date.After(end) && ctx.Err() == nil; date = date.AddDays(1) {
+		if _, ok := stored[date]; !ok {
 			continue
 		}
 
+		apod, err := w.provider.GetByDate(ctx, date)
+		if errors.Is(err, providers.ErrRateLimited) || errors.Is(err, providers.ErrUnauthorized) {
+			w.logger.Error("Stopped checking for corrections", slog.String("date", date.String()), sl.Err(err))
+			return
+		}
+		if errors.Is(err, providers.ErrNoPicture) {
+			continue
+		}
 		if err != nil {
-			w.logger.Error("Failed to save APOD", sl.Err(err))
-		} else {
-			w.logger.Info("APOD saved successfully")
+			w.logger.Error("Failed to get APOD to check for corrections", slog.String("date", date.String()), sl.Err(err))
+			continue
+		}
+
+		result, err := w.upsert(ctx, apod)
+		if err != nil {
+			w.logger.Error("Failed to save corrected APOD", slog.String("date", date.String()), sl.Err(err))
+			continue
+		}
+		if result == storage.UpsertUnchanged {
+			continue
 		}
 
-		errCount = 0
-		time.Sleep(24 * time.Hour)
+		w.logger.Info("APOD updated with corrections", slog.String("date", date.String()))
+		w.archive(ctx, apod)
+	}
+}
+
+// storedDates returns the window of the last days days up to yesterday and the
+// dates within it that are stored. It reports false if the window is empty or
+// the dates cannot be read.
+func (w *APODWorkerImpl) storedDates(ctx context.Context, today apod_date.Date, days int) (apod_date.Date, apod_date.Date, map[apod_date.Date]struct{}, bool) {
+	if days <= 0 {
+		return apod_date.Date{}, apod_date.Date{}, nil, false
+	}
+
+	end := today.AddDays(-1)
+	start := end.AddDays(-(days - 1))
+	if start.Before(apod_date.Epoch) {
+		start = apod_date.Epoch
+	}
+
+	dates, err := w.storage.GetAPODDates(ctx, w.provider.Source(), start, end)
+	if err != nil {
+		w.logger.Error("Failed to get stored APOD dates", sl.Err(err))
+		return apod_date.Date{}, apod_date.Date{}, nil, false
+	}
+
+	stored := make(map[apod_date.Date]struct{}, len(dates))
+	for _, date := range dates {
+		stored[date] = struct{}{}
+	}
+
+	return start, end, stored, true
+}
+
+// save stores a fetched APOD. It is not interrupted by the cancellation of ctx,
+// so an APOD that was already downloaded is not lost on shutdown.
+func (w *APODWorkerImpl) save(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	if err := w.storage.SaveAPOD(context.WithoutCancel(ctx), apod); err != nil {
+		return err
+	}
+	w.invalidate(ctx, apod)
+
+	return nil
+}
+
+// upsert stores a fetched APOD like save, overwriting a stored entry whose
+// content differs. Cached reads are only invalidated if the entry changed.
+func (w *APODWorkerImpl) upsert(ctx context.Context, apod *stellar_journal_models.APOD) (storage.Upsert, error) {
+	result, err := w.storage.UpsertAPOD(context.WithoutCancel(ctx), apod)
+	if err != nil {
+		return result, err
+	}
+	if result != storage.UpsertUnchanged {
+		w.invalidate(ctx, apod)
+	}
+
+	return result, nil
+}
+
+// archive downloads the images of a saved APOD. The derivatives it records are
+// part of the journal entry, so cached reads are invalidated again afterwards.
+func (w *APODWorkerImpl) archive(ctx context.Context, apod *stellar_journal_models.APOD) {
+	if w.archiver == nil {
+		return
+	}
+
+	if err := w.archiver.Archive(ctx, apod); err != nil {
+		w.logger.Error("Failed to archive APOD media", slog.String("date", apod.Date.String()), sl.Err(err))
 	}
+	w.invalidate(ctx, apod)
+}
+
+func (w *APODWorkerImpl) invalidate(ctx context.Context, apod *stellar_journal_models.APOD) {
+	if w.cache == nil {
+		return
+	}
+
+	if err := w.cache.Invalidate(context.WithoutCancel(ctx), apod); err != nil {
+		w.logger.Error("Failed to invalidate cached reads", slog.String("date", apod.Date.String()), sl.Err(err))
+	}
+}
+
+// Workers are the workers of the enabled sources by source.
+type Workers map[string]*APODWorkerImpl
+
+// Fetch fetches the picture of the source with its worker, see
+// APODWorkerImpl.Fetch.
+func (ws Workers) Fetch(ctx context.Context, source string, date apod_date.Date, overwrite bool) (*stellar_journal_models.APOD, bool, error) {
+	const op = "internal/apod_worker.Workers.Fetch"
+
+	w, ok := ws[source]
+	if !ok {
+		return nil, false, fmt.Errorf("%s: %s: %w", op, source, ErrUnknownSource)
+	}
+
+	return w.Fetch(ctx, date, overwrite)
+}
+
+// Stats returns the stats of every worker by source.
+func (ws Workers) Stats() map[string]Stats {
+	stats := make(map[string]Stats, len(ws))
+	for source, w := range ws {
+		stats[source] = w.Stats()
+	}
+
+	return stats
 }
@@ -1,78 +1,575 @@
 package apod_worker_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/lib/clock/fakeclock"
 	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
-	"stellar_journal/internal/models/nasa_api_models"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/scheduler"
 	"stellar_journal/internal/storage"
 	"testing"
 	"time"
 )
 
-type MockAPODAPI struct {
+type MockProvider struct {
 	mock.Mock
 }
 
-func (m *MockAPODAPI) GetAPOD() (*nasa_api_models.APODResp, error) {
-	args := m.Called()
-	return args.Get(0).(*nasa_api_models.APODResp), args.Error(1)
+func (m *MockProvider) Source() string {
+	return stellar_journal_models.SourceNASAAPOD
+}
+
+func (m *MockProvider) GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	args := m.Called(ctx, date)
+	apod, _ := args.Get(0).(*stellar_journal_models.APOD)
+	return apod, args.Error(1)
 }
 
 type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) SaveAPOD(apod *nasa_api_models.APODResp) error {
-	args := m.Called(apod)
+func (m *MockStorage) SaveAPOD(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	args := m.Called(ctx, apod)
 	return args.Error(0)
 }
 
-func TestAPODWorkerImpl_Run(t *testing.T) {
-	mockAPODAPI := new(MockAPODAPI)
-	mockStorage := new(MockStorage)
-	logger := slogdiscard.NewDiscardLogger()
+func (m *MockStorage) UpsertAPOD(ctx context.Context, apod *stellar_journal_models.APOD) (storage.Upsert, error) {
+	args := m.Called(ctx, apod)
+	return args.Get(0).(storage.Upsert), args.Error(1)
+}
+
+func (m *MockStorage) GetAPODDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	args := m.Called(ctx, source, startDate, endDate)
+	dates, _ := args.Get(0).([]apod_date.Date)
+	return dates, args.Error(1)
+}
+
+func (m *MockStorage) GetNoPictureDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	args := m.Called(ctx, source, startDate, endDate)
+	dates, _ := args.Get(0).([]apod_date.Date)
+	return dates, args.Error(1)
+}
 
-	worker := apod_worker.NewAPODWorker(mockAPODAPI, mockStorage, logger)
+func (m *MockStorage) SaveNoPictureDates(ctx context.Context, source string, dates []apod_date.Date) error {
+	args := m.Called(ctx, source, dates)
+	return args.Error(0)
+}
+
+type MockCache struct {
+	mock.Mock
+}
+
+func (m *MockCache) Invalidate(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	args := m.Called(ctx, apod)
+	return args.Error(0)
+}
+
+type MockArchiver struct {
+	mock.Mock
+}
+
+func (m *MockArchiver) Archive(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	args := m.Called(ctx, apod)
+	return args.Error(0)
+}
+
+// The worker runs daily at 00:05 New York time; every test starts five minutes
+// before the run on 2024-01-02.
+var (
+	start    = time.Date(2024, time.January, 2, 0, 0, 0, 0, apod_date.Location)
+	runAt    = start.Add(5 * time.Minute)
+	today    = apod_date.MustParse("2024-01-02")
+	schedule = scheduler.Daily(0, 5, apod_date.Location)
+	retry    = backoff.Policy{Initial: time.Minute, Max: time.Hour, Multiplier: 2, MaxAttempts: 3}
+	logger   = slogdiscard.NewDiscardLogger()
+)
+
+// runWorker starts the worker on a fake clock and waits until it is idle.
+func runWorker(t *testing.T, provider apod_worker.Provider, st apod_worker.Storage, archiver apod_worker.MediaArchiver, cache apod_worker.CacheInvalidator, lookback apod_worker.Lookback) *fakeclock.Clock {
+	t.Helper()
+
+	clk := fakeclock.New(start)
+	sched := scheduler.New(schedule, retry, false, clk, logger)
+	worker := apod_worker.NewAPODWorker(provider, st, archiver, cache, sched, logger, lookback)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+
+	clk.BlockUntil(1)
+
+	return clk
+}
+
+// tick advances the clock to the next timer and waits until the worker is idle
+// again.
+func tick(clk *fakeclock.Clock) {
+	clk.Advance(clk.Next().Sub(clk.Now()))
+	clk.BlockUntil(1)
+}
+
+func TestAPODWorkerImpl_Run(t *testing.T) {
+	todayAPOD := &stellar_journal_models.APOD{Date: today}
 
 	t.Run("HappyPath", func(t *testing.T) {
-		mockAPODAPI.On("GetAPOD").Return(&nasa_api_models.APODResp{}, nil)
-		mockStorage.On("SaveAPOD", mock.Anything).Return(nil)
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+		require.Equal(t, runAt, clk.Next())
 
-		go worker.Run()
-		time.Sleep(1 * time.Second)
-		mockAPODAPI.AssertExpectations(t)
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next())
+		mockProvider.AssertExpectations(t)
 		mockStorage.AssertExpectations(t)
 	})
 
 	t.Run("GetAPODFails", func(t *testing.T) {
-		mockAPODAPI.On("GetAPOD").Return(nil, errors.New("error"))
-		mockStorage.On("SaveAPOD", mock.Anything).Return(nil)
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(nil, errors.New("error")).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
 
-		go worker.Run()
-		time.Sleep(1 * time.Second)
-		mockAPODAPI.AssertExpectations(t)
+		tick(clk)
+		require.Equal(t, runAt.Add(retry.Initial), clk.Next())
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next())
+		mockProvider.AssertExpectations(t)
 		mockStorage.AssertExpectations(t)
 	})
 
 	t.Run("SaveAPODFails", func(t *testing.T) {
-		mockAPODAPI.On("GetAPOD").Return(&nasa_api_models.APODResp{}, nil)
-		mockStorage.On("SaveAPOD", mock.Anything).Return(errors.New("error"))
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Times(retry.MaxAttempts)
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertUnchanged, errors.New("error")).Times(retry.MaxAttempts)
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.Add(time.Minute), clk.Next())
+		tick(clk)
+		require.Equal(t, runAt.Add(3*time.Minute), clk.Next())
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next(), "gives up after max attempts")
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("NotPublishedYet", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(nil, fmt.Errorf("error: %w", providers.ErrNotPublishedYet)).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.Add(retry.Initial), clk.Next())
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next())
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("NoPicture", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(nil, fmt.Errorf("error: %w", providers.ErrNoPicture)).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next(), "days without a picture are not retried")
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(nil, fmt.Errorf("error: %w", providers.ErrUnauthorized)).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next(), "rejected API keys are not retried")
+		mockProvider.AssertExpectations(t)
+	})
 
-		go worker.Run()
-		time.Sleep(1 * time.Second)
-		mockAPODAPI.AssertExpectations(t)
+	t.Run("APODUnchanged", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertUnchanged, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next())
+		mockProvider.AssertExpectations(t)
 		mockStorage.AssertExpectations(t)
 	})
 
-	t.Run("APODAlreadyExists", func(t *testing.T) {
-		mockAPODAPI.On("GetAPOD").Return(&nasa_api_models.APODResp{}, nil)
-		mockStorage.On("SaveAPOD", mock.Anything).Return(storage.ErrAPODExists)
+	t.Run("FillsGaps", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+
+		yesterday := today.AddDays(-1)
+		dayBefore := yesterday.AddDays(-1)
+		missing := &stellar_journal_models.APOD{Date: dayBefore}
+
+		mockStorage.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, dayBefore, yesterday).
+			Return([]apod_date.Date{yesterday}, nil).Once()
+		mockStorage.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, dayBefore, yesterday).
+			Return([]apod_date.Date{}, nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, dayBefore).Return(missing, nil).Once()
+		mockStorage.On("SaveAPOD", mock.Anything, missing).Return(nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{GapDays: 2})
+
+		tick(clk)
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("SkipsDaysWithoutAPicture", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+
+		yesterday := today.AddDays(-1)
+		dayBefore := yesterday.AddDays(-1)
+
+		mockStorage.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, dayBefore, yesterday).
+			Return([]apod_date.Date{}, nil).Once()
+		mockStorage.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, dayBefore, yesterday).
+			Return([]apod_date.Date{dayBefore}, nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, yesterday).Return(nil, fmt.Errorf("error: %w", providers.ErrNoPicture)).Once()
+		mockStorage.On("SaveNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, []apod_date.Date{yesterday}).Return(nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertUnchanged, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{GapDays: 2})
 
-		go worker.Run()
-		time.Sleep(1 * time.Second)
-		mockAPODAPI.AssertExpectations(t)
+		tick(clk)
+		mockProvider.AssertExpectations(t)
 		mockStorage.AssertExpectations(t)
 	})
+
+	t.Run("PicksUpCorrections", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockArchiver := new(MockArchiver)
+
+		yesterday := today.AddDays(-1)
+		dayBefore := yesterday.AddDays(-1)
+		corrected := &stellar_journal_models.APOD{Date: yesterday, MediaType: "image", Title: "Corrected"}
+		same := &stellar_journal_models.APOD{Date: dayBefore, Title: "Unchanged"}
+
+		mockStorage.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, dayBefore, yesterday).
+			Return([]apod_date.Date{dayBefore, yesterday}, nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, dayBefore).Return(same, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, same).Return(storage.UpsertUnchanged, nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, yesterday).Return(corrected, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, corrected).Return(storage.UpsertUpdated, nil).Once()
+		mockArchiver.On("Archive", mock.Anything, corrected).Return(nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertUnchanged, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, mockArchiver, nil, apod_worker.Lookback{CorrectionDays: 2})
+
+		tick(clk)
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+		mockArchiver.AssertExpectations(t)
+	})
+
+	t.Run("StopsFillingGapsWhenRateLimited", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+
+		yesterday := today.AddDays(-1)
+		mockStorage.On("GetAPODDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, yesterday.AddDays(-2), yesterday).
+			Return([]apod_date.Date{}, nil).Once()
+		mockStorage.On("GetNoPictureDates", mock.Anything, stellar_journal_models.SourceNASAAPOD, yesterday.AddDays(-2), yesterday).
+			Return([]apod_date.Date{}, nil).Once()
+		mockProvider.On("GetByDate", mock.Anything, yesterday.AddDays(-2)).
+			Return(nil, fmt.Errorf("error: %w", providers.ErrRateLimited)).Once()
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, nil, nil, apod_worker.Lookback{GapDays: 3})
+
+		tick(clk)
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("ArchivesSavedAPOD", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockArchiver := new(MockArchiver)
+
+		apod := &stellar_journal_models.APOD{Date: today, MediaType: "image"}
+		mockProvider.On("GetByDate", mock.Anything, today).Return(apod, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, apod).Return(storage.UpsertCreated, nil).Once()
+		mockArchiver.On("Archive", mock.Anything, apod).Return(errors.New("error")).Once()
+
+		clk := runWorker(t, mockProvider, mockStorage, mockArchiver, nil, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next(), "archive failures are not retried")
+		mockArchiver.AssertExpectations(t)
+	})
+
+	t.Run("InvalidatesCache", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockArchiver := new(MockArchiver)
+		mockCache := new(MockCache)
+
+		apod := &stellar_journal_models.APOD{Date: today, MediaType: "image"}
+		mockProvider.On("GetByDate", mock.Anything, mock.Anything).Return(apod, nil).Twice()
+		mockStorage.On("UpsertAPOD", mock.Anything, apod).Return(storage.UpsertCreated, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, apod).Return(storage.UpsertUnchanged, nil).Once()
+		mockArchiver.On("Archive", mock.Anything, apod).Return(nil).Once()
+		mockCache.On("Invalidate", mock.Anything, apod).Return(errors.New("connection refused")).Twice()
+
+		clk := runWorker(t, mockProvider, mockStorage, mockArchiver, mockCache, apod_worker.Lookback{})
+
+		tick(clk)
+		require.Equal(t, runAt.AddDate(0, 0, 1), clk.Next(), "invalidation failures are not retried")
+
+		tick(clk)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("UpdatesCorrectedAPOD", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockArchiver := new(MockArchiver)
+		mockCache := new(MockCache)
+
+		apod := &stellar_journal_models.APOD{Date: today, MediaType: "image", Title: "Corrected"}
+		mockProvider.On("GetByDate", mock.Anything, today).Return(apod, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, apod).Return(storage.UpsertUpdated, nil).Once()
+		mockArchiver.On("Archive", mock.Anything, apod).Return(nil).Once()
+		mockCache.On("Invalidate", mock.Anything, apod).Return(nil).Twice()
+
+		clk := runWorker(t, mockProvider, mockStorage, mockArchiver, mockCache, apod_worker.Lookback{})
+
+		tick(clk)
+		mockStorage.AssertExpectations(t)
+		mockArchiver.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("RunsOnStart", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Return(storage.UpsertCreated, nil).Once()
+
+		clk := fakeclock.New(start)
+		sched := scheduler.New(schedule, retry, true, clk, logger)
+		worker := apod_worker.NewAPODWorker(mockProvider, mockStorage, nil, nil, sched, logger, apod_worker.Lookback{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+
+		clk.BlockUntil(1)
+		require.Equal(t, runAt, clk.Next())
+		require.True(t, start.Equal(worker.LastSuccess()), "last success is %s", worker.LastSuccess())
+		stats := worker.Stats()
+		require.Equal(t, uint64(1), stats.Successes)
+		require.Zero(t, stats.Failures)
+		require.True(t, start.Equal(stats.LastRun), "last run is %s", stats.LastRun)
+		require.True(t, runAt.Equal(stats.NextRun), "next run is %s", stats.NextRun)
+		require.Empty(t, stats.LastError)
+		mockProvider.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("StopsOnCancel", func(t *testing.T) {
+		clk := fakeclock.New(start)
+		sched := scheduler.New(schedule, retry, false, clk, logger)
+		worker := apod_worker.NewAPODWorker(new(MockProvider), new(MockStorage), nil, nil, sched, logger, apod_worker.Lookback{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			worker.Run(ctx)
+			close(stopped)
+		}()
+
+		clk.BlockUntil(1)
+		require.True(t, worker.LastSuccess().IsZero())
+		cancel()
+
+		// Run returns once cancelled; a hang fails the test at its timeout.
+		<-stopped
+	})
+
+	t.Run("DrainsInFlightSave", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		mockStorage := new(MockStorage)
+
+		clk := fakeclock.New(start)
+		sched := scheduler.New(schedule, retry, false, clk, logger)
+		worker := apod_worker.NewAPODWorker(mockProvider, mockStorage, nil, nil, sched, logger, apod_worker.Lookback{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var saveErr error
+		mockProvider.On("GetByDate", mock.Anything, today).Return(todayAPOD, nil).Once()
+		mockStorage.On("UpsertAPOD", mock.Anything, todayAPOD).Run(func(args mock.Arguments) {
+			cancel()
+			saveErr = args.Get(0).(context.Context).Err()
+		}).Return(storage.UpsertCreated, nil).Once()
+
+		stopped := make(chan struct{})
+		go func() {
+			worker.Run(ctx)
+			close(stopped)
+		}()
+
+		clk.BlockUntil(1)
+		clk.Advance(5 * time.Minute)
+
+		// Run returns once cancelled; a hang fails the test at its timeout.
+		<-stopped
+		require.NoError(t, saveErr, "save context must not be cancelled")
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestAPODWorkerImpl_Stats(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockStorage := new(MockStorage)
+	mockProvider.On("GetByDate", mock.Anything, today).Return(nil, errors.New("connection refused")).Once()
+	mockProvider.On("GetByDate", mock.Anything, today).Return(&stellar_journal_models.APOD{Date: today}, nil).Once()
+	mockStorage.On("UpsertAPOD", mock.Anything, mock.Anything).Return(storage.UpsertCreated, nil).Once()
+
+	clk := fakeclock.New(start)
+	sched := scheduler.New(schedule, retry, true, clk, logger)
+	worker := apod_worker.NewAPODWorker(mockProvider, mockStorage, nil, nil, sched, logger, apod_worker.Lookback{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go worker.Run(ctx)
+
+	clk.BlockUntil(1)
+	stats := worker.Stats()
+	require.Equal(t, uint64(1), stats.Failures)
+	require.Contains(t, stats.LastError, "connection refused")
+	require.True(t, start.Equal(stats.LastRun), "last run is %s", stats.LastRun)
+	require.True(t, start.Add(retry.Initial).Equal(stats.NextRun), "the retry is the next run, got %s", stats.NextRun)
+	require.True(t, stats.LastSuccess.IsZero())
+
+	tick(clk)
+	stats = worker.Stats()
+	require.Equal(t, uint64(1), stats.Successes)
+	require.Empty(t, stats.LastError, "a success clears the last error")
+	require.True(t, runAt.Equal(stats.NextRun), "next run is %s", stats.NextRun)
+}
+
+func TestAPODWorkerImpl_Fetch(t *testing.T) {
+	date := apod_date.MustParse("2023-12-24")
+	apod := &stellar_journal_models.APOD{Date: date}
+
+	cases := []struct {
+		name      string
+		overwrite bool
+		saveErr   error
+		upsert    storage.Upsert
+		created   bool
+		err       error
+	}{
+		{name: "Created", created: true},
+		{name: "Exists", saveErr: fmt.Errorf("error: %w", storage.ErrAPODExists), err: storage.ErrAPODExists},
+		{name: "Overwritten", overwrite: true, upsert: storage.UpsertUpdated},
+		{name: "Overwrite Unchanged", overwrite: true, upsert: storage.UpsertUnchanged},
+		{name: "Overwrite Creates", overwrite: true, upsert: storage.UpsertCreated, created: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockProvider := new(MockProvider)
+			mockStorage := new(MockStorage)
+			mockArchiver := new(MockArchiver)
+			mockCache := new(MockCache)
+			mockProvider.On("GetByDate", mock.Anything, date).Return(apod, nil).Once()
+			if tc.overwrite {
+				mockStorage.On("UpsertAPOD", mock.Anything, apod).Return(tc.upsert, nil).Once()
+			} else {
+				mockStorage.On("SaveAPOD", mock.Anything, apod).Return(tc.saveErr).Once()
+			}
+			if tc.err == nil {
+				mockArchiver.On("Archive", mock.Anything, apod).Return(nil).Once()
+				// An unchanged entry is only invalidated after archiving.
+				invalidations := 2
+				if tc.overwrite && tc.upsert == storage.UpsertUnchanged {
+					invalidations = 1
+				}
+				mockCache.On("Invalidate", mock.Anything, apod).Return(nil).Times(invalidations)
+			}
+
+			sched := scheduler.New(schedule, retry, false, fakeclock.New(start), logger)
+			worker := apod_worker.NewAPODWorker(mockProvider, mockStorage, mockArchiver, mockCache, sched, logger, apod_worker.Lookback{})
+
+			fetched, created, err := worker.Fetch(context.Background(), date, tc.overwrite)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				mockArchiver.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, apod, fetched)
+			require.Equal(t, tc.created, created)
+			mockStorage.AssertExpectations(t)
+			mockArchiver.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWorkers_Fetch(t *testing.T) {
+	sched := scheduler.New(schedule, retry, false, fakeclock.New(start), logger)
+	workers := apod_worker.Workers{
+		stellar_journal_models.SourceNASAAPOD: apod_worker.NewAPODWorker(new(MockProvider), new(MockStorage), nil, nil, sched, logger, apod_worker.Lookback{}),
+	}
+
+	_, _, err := workers.Fetch(context.Background(), stellar_journal_models.SourceBing, today, false)
+	require.ErrorIs(t, err, apod_worker.ErrUnknownSource)
+	require.Contains(t, workers.Stats(), stellar_journal_models.SourceNASAAPOD)
 }
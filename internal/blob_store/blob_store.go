@@ -0,0 +1,15 @@
+package blob_store
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// Store keeps binary objects under slash-separated keys such as "2024-01-01/hd.jpg".
+type Store interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
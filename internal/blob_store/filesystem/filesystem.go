@@ -0,0 +1,105 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"stellar_journal/internal/blob_store"
+)
+
+// Store keeps blobs as files below a root directory.
+type Store struct {
+	root string
+}
+
+func NewStore(root string) (*Store, error) {
+	const op = "internal/blob_store/filesystem.NewStore"
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: failed to create root directory: %w", op, err)
+	}
+
+	return &Store{root: root}, nil
+}
+
+// Put writes the blob to a temporary file first, so readers never see partial data.
+func (s *Store) Put(key string, r io.Reader) error {
+	const op = "internal/blob_store/filesystem.Put"
+
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("%s: failed to create directory: %w", op, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%s: failed to create temp file: %w", op, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("%s: failed to write blob: %w", op, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%s: failed to close blob: %w", op, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%s: failed to move blob: %w", op, err)
+	}
+
+	return nil
+}
+
+// Get returns the blob as an *os.File, which also implements io.ReadSeeker.
+func (s *Store) Get(key string) (io.ReadCloser, error) {
+	const op = "internal/blob_store/filesystem.Get"
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", op, blob_store.ErrBlobNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open blob: %w", op, err)
+	}
+
+	return f, nil
+}
+
+func (s *Store) Delete(key string) error {
+	const op = "internal/blob_store/filesystem.Delete"
+
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: failed to remove blob: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Store) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
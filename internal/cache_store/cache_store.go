@@ -0,0 +1,20 @@
+package cache_store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrMiss = errors.New("cache miss")
+
+// Store keeps values under string keys for a limited time. Values may be
+// evicted before they expire, so a Store only speeds up reads of data kept
+// elsewhere.
+type Store interface {
+	// Get returns ErrMiss when the key is not cached.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set caches the value for ttl; a zero ttl keeps it until it is evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
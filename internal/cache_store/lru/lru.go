@@ -0,0 +1,107 @@
+package lru
+
+import (
+	"container/list"
+	"context"
+	"stellar_journal/internal/cache_store"
+	"stellar_journal/internal/lib/clock"
+	"sync"
+	"time"
+)
+
+// Store is an in-memory cache_store.Store that evicts the least recently used
+// entry once it holds maxEntries entries.
+type Store struct {
+	mu         sync.Mutex
+	maxEntries int
+	clock      clock.Clock
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewStore(maxEntries int, clk clock.Clock) *Store {
+	return &Store{
+		maxEntries: maxEntries,
+		clock:      clk,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (s *Store) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, cache_store.ErrMiss
+	}
+
+	e := el.Value.(*entry)
+	if !e.expiresAt.IsZero() && !s.clock.Now().Before(e.expiresAt) {
+		s.remove(el)
+		return nil, cache_store.ErrMiss
+	}
+
+	s.order.MoveToFront(el)
+
+	return e.value, nil
+}
+
+func (s *Store) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = s.clock.Now().Add(ttl)
+	}
+
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		s.order.MoveToFront(el)
+
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.entries[key]; ok {
+			s.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of cached entries, including expired ones that were
+// not evicted yet.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+func (s *Store) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*entry).key)
+}
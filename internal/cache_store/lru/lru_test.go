@@ -0,0 +1,63 @@
+package lru_test
+
+import (
+	"context"
+	"stellar_journal/internal/cache_store"
+	"stellar_journal/internal/cache_store/lru"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := lru.NewStore(2, fakeclock.New(time.Now()))
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+
+	value, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), value)
+
+	require.NoError(t, store.Set(ctx, "c", []byte("3"), 0))
+	require.Equal(t, 2, store.Len())
+
+	_, err = store.Get(ctx, "b")
+	require.ErrorIs(t, err, cache_store.ErrMiss)
+	_, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	_, err = store.Get(ctx, "c")
+	require.NoError(t, err)
+}
+
+func TestStoreExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	clk := fakeclock.New(time.Now())
+	store := lru.NewStore(10, clk)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+
+	clk.Advance(time.Minute)
+
+	_, err := store.Get(ctx, "a")
+	require.ErrorIs(t, err, cache_store.ErrMiss)
+	_, err = store.Get(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, 1, store.Len())
+}
+
+func TestStoreDelete(t *testing.T) {
+	ctx := context.Background()
+	store := lru.NewStore(10, fakeclock.New(time.Now()))
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, store.Set(ctx, "a", []byte("2"), 0))
+	require.NoError(t, store.Delete(ctx, "a", "missing"))
+
+	_, err := store.Get(ctx, "a")
+	require.ErrorIs(t, err, cache_store.ErrMiss)
+}
@@ -0,0 +1,336 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"stellar_journal/internal/cache_store"
+	"strconv"
+	"time"
+)
+
+// Options configure the connections of a Store.
+type Options struct {
+	Password string
+	DB       int
+	// Timeout bounds dialing and every command unless the context of the
+	// command has an earlier deadline.
+	Timeout time.Duration
+	// PoolSize is the number of idle connections kept for reuse.
+	PoolSize int
+}
+
+// Store is a cache_store.Store kept in a server speaking the Redis
+// serialization protocol (RESP), such as Redis, Valkey or KeyDB.
+type Store struct {
+	addr string
+	opts Options
+	idle chan *conn
+}
+
+// ServerError is an error reply of the server.
+type ServerError string
+
+func (e ServerError) Error() string {
+	return string(e)
+}
+
+func NewStore(addr string, opts Options) *Store {
+	return &Store{
+		addr: addr,
+		opts: opts,
+		idle: make(chan *conn, max(opts.PoolSize, 1)),
+	}
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	const op = "internal/cache_store/resp.Get"
+
+	reply, err := s.do(ctx, "GET", key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if reply == nil {
+		return nil, cache_store.ErrMiss
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%s: unexpected reply %v", op, reply)
+	}
+
+	return value, nil
+}
+
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	const op = "internal/cache_store/resp.Set"
+
+	args := []any{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	if _, err := s.do(ctx, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, keys ...string) error {
+	const op = "internal/cache_store/resp.Delete"
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := []any{"DEL"}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	if _, err := s.do(ctx, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Ping checks that the server is reachable.
+func (s *Store) Ping(ctx context.Context) error {
+	const op = "internal/cache_store/resp.Ping"
+
+	if _, err := s.do(ctx, "PING"); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Do sends a command the Store has no method for, such as EVAL, and returns its
+// reply as decoded by readReply. Error replies are returned as ServerError.
+func (s *Store) Do(ctx context.Context, args ...any) (any, error) {
+	const op = "internal/cache_store/resp.Do"
+
+	reply, err := s.do(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reply, nil
+}
+
+// Close closes the idle connections.
+func (s *Store) Close() error {
+	for {
+		select {
+		case c := <-s.idle:
+			_ = c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command on a pooled connection and reads its reply. Connections
+// that failed are closed instead of being returned to the pool.
+func (s *Store) do(ctx context.Context, args ...any) (any, error) {
+	c, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.do(ctx, s.opts.Timeout, args...)
+	var serverErr ServerError
+	if err != nil && !errors.As(err, &serverErr) {
+		_ = c.Close()
+		return nil, err
+	}
+
+	select {
+	case s.idle <- c:
+	default:
+		_ = c.Close()
+	}
+
+	return reply, err
+}
+
+func (s *Store) conn(ctx context.Context) (*conn, error) {
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: s.opts.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if s.opts.Password != "" {
+		if _, err := c.do(ctx, s.opts.Timeout, "AUTH", s.opts.Password); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if s.opts.DB != 0 {
+		if _, err := c.do(ctx, s.opts.Timeout, "SELECT", strconv.Itoa(s.opts.DB)); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("failed to select database: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (c *conn) do(ctx context.Context, timeout time.Duration, args ...any) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if timeout > 0 && (!ok || time.Now().Add(timeout).Before(deadline)) {
+		deadline, ok = time.Now().Add(timeout), true
+	}
+	if ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	} else if err := c.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	if err := writeCommand(c.w, args); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	reply, err := readReply(c.r)
+	if err != nil {
+		var serverErr ServerError
+		if errors.As(err, &serverErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+
+	return reply, nil
+}
+
+// writeCommand encodes a command as an array of bulk strings.
+func writeCommand(w *bufio.Writer, args []any) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		default:
+			return fmt.Errorf("unsupported argument type %T", arg)
+		}
+
+		if _, err := fmt.Fprintf(w, "$%d\r\n", len(b)); err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// readReply decodes a RESP2 reply: simple strings as string, bulk strings as
+// []byte, integers as int64, arrays as []any and nil bulk strings and arrays as
+// nil. Error replies are returned as ServerError.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, ServerError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		// An error element, such as a failed command of an EXEC, is returned
+		// once the rest of the array is read, so that the connection stays
+		// in step with the server and can be reused.
+		items := make([]any, n)
+		var replyErr error
+		for i := range items {
+			items[i], err = readReply(r)
+			var serverErr ServerError
+			if err != nil && !errors.As(err, &serverErr) {
+				return nil, err
+			}
+			if err != nil && replyErr == nil {
+				replyErr = err
+			}
+		}
+		if replyErr != nil {
+			return nil, replyErr
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("unexpected reply type %q", line[0])
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("malformed reply line")
+	}
+
+	return line[:len(line)-2], nil
+}
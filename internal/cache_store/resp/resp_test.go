@@ -0,0 +1,247 @@
+package resp_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"stellar_journal/internal/cache_store"
+	"stellar_journal/internal/cache_store/resp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// server is a stand-in for a RESP server supporting the commands the store
+// sends. Expiry is recorded but not enforced.
+type server struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	conns    int
+	commands []string
+}
+
+func startServer(t *testing.T, password string) *server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &server{listener: listener, password: password, values: make(map[string]string), ttls: make(map[string]string)}
+	go s.serve()
+
+	return s
+}
+
+func (s *server) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *server) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+
+		go s.handle(c)
+	}
+}
+
+func (s *server) handle(c net.Conn) {
+	defer func() { _ = c.Close() }()
+
+	r := bufio.NewReader(c)
+	authenticated := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.ToUpper(args[0]))
+		reply := "-ERR unknown command\r\n"
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authenticated = args[1] == s.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "PING":
+			reply = "+PONG\r\n"
+		case cmd == "EXEC":
+			reply = "*3\r\n:1\r\n-ERR nested\r\n*1\r\n-ERR deeper\r\n"
+		case cmd == "SELECT":
+			reply = "+OK\r\n"
+		case cmd == "ECHO":
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(args[1]), args[1])
+		case cmd == "GET":
+			if v, ok := s.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case cmd == "SET":
+			s.values[args[1]] = args[2]
+			delete(s.ttls, args[1])
+			if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+				s.ttls[args[1]] = args[4]
+			}
+			reply = "+OK\r\n"
+		case cmd == "DEL":
+			n := 0
+			for _, key := range args[1:] {
+				if _, ok := s.values[key]; ok {
+					delete(s.values, key)
+					n++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", n)
+		}
+		s.mu.Unlock()
+
+		if _, err := io.WriteString(c, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+
+	return args, nil
+}
+
+func TestStore(t *testing.T) {
+	srv := startServer(t, "")
+	store := resp.NewStore(srv.addr(), resp.Options{Timeout: time.Second, PoolSize: 2})
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+
+	require.NoError(t, store.Ping(ctx))
+
+	_, err := store.Get(ctx, "apod")
+	require.ErrorIs(t, err, cache_store.ErrMiss)
+
+	value := []byte("{\"title\":\"Galaxy\"}\r\n$-1\r\n")
+	require.NoError(t, store.Set(ctx, "apod", value, 90*time.Second))
+
+	got, err := store.Get(ctx, "apod")
+	require.NoError(t, err)
+	require.Equal(t, value, got)
+
+	require.NoError(t, store.Set(ctx, "version", []byte("1"), 0))
+	require.NoError(t, store.Delete(ctx, "apod", "missing"))
+
+	_, err = store.Get(ctx, "apod")
+	require.ErrorIs(t, err, cache_store.ErrMiss)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	require.Equal(t, "90000", srv.ttls["apod"])
+	require.NotContains(t, srv.ttls, "version")
+	require.Equal(t, 1, srv.conns, "connections are reused")
+}
+
+func TestStoreDo(t *testing.T) {
+	srv := startServer(t, "")
+	store := resp.NewStore(srv.addr(), resp.Options{Timeout: time.Second})
+	t.Cleanup(func() { _ = store.Close() })
+
+	reply, err := store.Do(context.Background(), "ECHO", "galaxy")
+	require.NoError(t, err)
+	require.Equal(t, []byte("galaxy"), reply)
+
+	_, err = store.Do(context.Background(), "EVAL", "return 1", "0")
+	var serverErr resp.ServerError
+	require.ErrorAs(t, err, &serverErr)
+
+	_, err = store.Do(context.Background(), "EXEC")
+	require.ErrorAs(t, err, &serverErr)
+	require.Equal(t, resp.ServerError("ERR nested"), serverErr)
+
+	reply, err = store.Do(context.Background(), "PING")
+	require.NoError(t, err)
+	require.Equal(t, "PONG", reply, "the rest of the array was read")
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	require.Equal(t, 1, srv.conns)
+}
+
+func TestStoreAuthenticates(t *testing.T) {
+	srv := startServer(t, "secret")
+	ctx := context.Background()
+
+	store := resp.NewStore(srv.addr(), resp.Options{Password: "secret", DB: 2, Timeout: time.Second})
+	t.Cleanup(func() { _ = store.Close() })
+
+	require.NoError(t, store.Set(ctx, "apod", []byte("1"), 0))
+
+	srv.mu.Lock()
+	require.Equal(t, []string{"AUTH", "SELECT", "SET"}, srv.commands)
+	srv.mu.Unlock()
+
+	wrong := resp.NewStore(srv.addr(), resp.Options{Password: "wrong", Timeout: time.Second})
+	t.Cleanup(func() { _ = wrong.Close() })
+
+	var serverErr resp.ServerError
+	require.ErrorAs(t, wrong.Ping(ctx), &serverErr)
+}
+
+func TestStoreUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	store := resp.NewStore(addr, resp.Options{Timeout: time.Second})
+
+	_, err = store.Get(context.Background(), "apod")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cache_store.ErrMiss)
+}
@@ -8,11 +8,21 @@ import (
 )
 
 type Config struct {
-	Env        string `yaml:"env" env-default:"local"`
-	HttpServer `yaml:"http_server"`
-	Storage    `yaml:"storage"`
-	NasaApi    `yaml:"nasa_api"`
-	CtxTimeout time.Duration `yaml:"ctx_timeout" env-default:"5s"`
+	Env          string `yaml:"env" env-default:"local"`
+	HttpServer   `yaml:"http_server"`
+	Storage      `yaml:"storage"`
+	NasaApi      `yaml:"nasa_api"`
+	APODWorker   `yaml:"apod_worker"`
+	Providers    `yaml:"providers"`
+	MediaArchive `yaml:"media_archive"`
+	ReadCache    `yaml:"read_cache"`
+	Health       `yaml:"health"`
+	Metrics      `yaml:"metrics"`
+	Tracing      `yaml:"tracing"`
+	Auth         `yaml:"auth"`
+	RateLimit    `yaml:"rate_limit"`
+	Admin        `yaml:"admin"`
+	CtxTimeout   time.Duration `yaml:"ctx_timeout" env-default:"5s"`
 }
 
 type HttpServer struct {
@@ -20,19 +30,207 @@ type HttpServer struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout" env-default:"4s"`
 	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"4s"`
 	IdleTimeout  time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Cache        HTTPCache     `yaml:"cache"`
 }
 
+// HTTPCache sets how long shared caches such as a CDN may serve journal
+// responses. Entries of past dates get MaxAge; today's entry, journal pages and
+// histories, which change daily, and the entries of the NASA APOD worker's
+// correction window, which may still be corrected, get RecentMaxAge.
+type HTTPCache struct {
+	MaxAge       time.Duration `yaml:"max_age" env-default:"168h"`
+	RecentMaxAge time.Duration `yaml:"recent_max_age" env-default:"5m"`
+}
+
+// Storage configures the Postgres connection pool. URL, a postgres:// URL or a
+// key=value connection string, takes precedence over the other connection
+// settings.
 type Storage struct {
-	MigrationsPath string `yaml:"migrations_path" env-required:"true"`
-	Name           string `yaml:"db_name" env-required:"true"`
-	User           string `yaml:"db_user" env-required:"true"`
-	Password       string `yaml:"db_password" env-required:"true"`
-	Host           string `yaml:"db_host" env-required:"true"`
+	URL          string       `yaml:"db_url"`
+	Host         string       `yaml:"db_host" env-default:"localhost"`
+	Port         int          `yaml:"db_port" env-default:"5432"`
+	Name         string       `yaml:"db_name"`
+	User         string       `yaml:"db_user"`
+	Password     string       `yaml:"db_password"`
+	SSLMode      string       `yaml:"db_sslmode" env-default:"disable"`
+	SSLRootCert  string       `yaml:"db_sslrootcert"`
+	Pool         DBPool       `yaml:"pool"`
+	ConnectRetry ConnectRetry `yaml:"connect_retry"`
+}
+
+type DBPool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"20"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"5"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+}
+
+// ConnectRetry configures how long startup waits for the database to accept
+// connections.
+type ConnectRetry struct {
+	InitialDelay time.Duration `yaml:"initial_delay" env-default:"500ms"`
+	MaxDelay     time.Duration `yaml:"max_delay" env-default:"5s"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"1m"`
 }
 
 type NasaApi struct {
-	Host  string `yaml:"host" env-required:"true"`
-	Token string `yaml:"token" env-required:"true"`
+	Host    string        `yaml:"host" env-required:"true"`
+	Token   string        `yaml:"token" env-required:"true"`
+	Timeout time.Duration `yaml:"timeout" env-default:"30s"`
+	Retry   RequestRetry  `yaml:"retry"`
+}
+
+// RequestRetry configures how failed NASA API requests are retried.
+type RequestRetry struct {
+	InitialDelay time.Duration `yaml:"initial_delay" env-default:"1s"`
+	MaxDelay     time.Duration `yaml:"max_delay" env-default:"1m"`
+	Jitter       float64       `yaml:"jitter" env-default:"0.2"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"4"`
+}
+
+type APODWorker struct {
+	GapLookbackDays        int      `yaml:"gap_lookback_days" env-default:"30"`
+	CorrectionLookbackDays int      `yaml:"correction_lookback_days" env-default:"3"`
+	Schedule               Schedule `yaml:"schedule"`
+}
+
+// Schedule configures when the APOD worker runs. Cron, a standard five-field
+// expression, takes precedence over DailyAt (HH:MM). Both are evaluated in
+// Timezone, which defaults to the one NASA publishes in.
+type Schedule struct {
+	Cron       string `yaml:"cron"`
+	DailyAt    string `yaml:"daily_at" env-default:"00:05"`
+	Timezone   string `yaml:"timezone" env-default:"America/New_York"`
+	RunOnStart bool   `yaml:"run_on_start" env-default:"true"`
+	Retry      Retry  `yaml:"retry"`
+}
+
+type Retry struct {
+	InitialDelay time.Duration `yaml:"initial_delay" env-default:"5m"`
+	MaxDelay     time.Duration `yaml:"max_delay" env-default:"2h"`
+	Multiplier   float64       `yaml:"multiplier" env-default:"2"`
+	Jitter       float64       `yaml:"jitter" env-default:"0.2"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"10"`
+}
+
+// Providers enables picture sources besides NASA APOD, each fetched by its own
+// worker. Cron and DailyAt select when the worker runs; the time zone, retries
+// and run on start are shared with the APOD worker schedule.
+type Providers struct {
+	Timeout   time.Duration `yaml:"timeout" env-default:"30s"`
+	Bing      Bing          `yaml:"bing"`
+	Wikimedia Wikimedia     `yaml:"wikimedia"`
+	ESAHubble ESAHubble     `yaml:"esa_hubble"`
+}
+
+type Bing struct {
+	Enabled         bool   `yaml:"enabled" env-default:"false"`
+	Host            string `yaml:"host" env-default:"https://www.bing.com"`
+	Market          string `yaml:"market" env-default:"en-US"`
+	Cron            string `yaml:"cron"`
+	DailyAt         string `yaml:"daily_at" env-default:"03:05"`
+	GapLookbackDays int    `yaml:"gap_lookback_days" env-default:"7"`
+}
+
+type Wikimedia struct {
+	Enabled         bool   `yaml:"enabled" env-default:"false"`
+	Host            string `yaml:"host" env-default:"https://api.wikimedia.org"`
+	Language        string `yaml:"language" env-default:"en"`
+	Cron            string `yaml:"cron"`
+	DailyAt         string `yaml:"daily_at" env-default:"00:05"`
+	GapLookbackDays int    `yaml:"gap_lookback_days" env-default:"30"`
+}
+
+type ESAHubble struct {
+	Enabled         bool   `yaml:"enabled" env-default:"false"`
+	Host            string `yaml:"host" env-default:"https://esahubble.org"`
+	Cron            string `yaml:"cron" env-default:"5 6 * * 1"`
+	DailyAt         string `yaml:"daily_at"`
+	GapLookbackDays int    `yaml:"gap_lookback_days" env-default:"28"`
+}
+
+type MediaArchive struct {
+	Enabled           bool          `yaml:"enabled" env-default:"false"`
+	Path              string        `yaml:"path" env-default:"./data/media"`
+	DownloadTimeout   time.Duration `yaml:"download_timeout" env-default:"2m"`
+	MaxDownloadSize   int64         `yaml:"max_download_size" env-default:"104857600"`
+	DerivativeWidths  []int         `yaml:"derivative_widths" env-default:"320,640,1280"`
+	DerivativeFormats []string      `yaml:"derivative_formats" env-default:"jpeg"`
+	MaxPixels         int64         `yaml:"max_pixels" env-default:"100000000"`
+}
+
+// ReadCache caches entries and journal pages read from the database. Backend
+// is none, memory (an LRU of MaxEntries per process) or resp (a Redis
+// compatible server shared by every instance).
+type ReadCache struct {
+	Backend    string        `yaml:"backend" env-default:"none"`
+	TTL        time.Duration `yaml:"ttl" env-default:"10m"`
+	MaxEntries int           `yaml:"max_entries" env-default:"10000"`
+	RESP       RESP          `yaml:"resp"`
+}
+
+type RESP struct {
+	Addr     string        `yaml:"addr" env-default:"localhost:6379"`
+	Password string        `yaml:"password"`
+	DB       int           `yaml:"db" env-default:"0"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"500ms"`
+	PoolSize int           `yaml:"pool_size" env-default:"10"`
+}
+
+// Health configures the readiness checks. The service is degraded when the NASA
+// APOD worker has not fetched for WorkerMaxAge or the newest NASA APOD entry is
+// more than LatestAPODMaxAgeDays old.
+type Health struct {
+	CheckTimeout         time.Duration `yaml:"check_timeout" env-default:"2s"`
+	WorkerMaxAge         time.Duration `yaml:"worker_max_age" env-default:"36h"`
+	LatestAPODMaxAgeDays int           `yaml:"latest_apod_max_age_days" env-default:"2"`
+}
+
+// Metrics serves Prometheus metrics at /metrics.
+type Metrics struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+}
+
+// Tracing exports OpenTelemetry spans. Exporter is none, stdout or otlp, which
+// sends them over HTTP to Endpoint, e.g. http://otel-collector:4318.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"stellar_journal"`
+}
+
+// Auth requires an API key for the journal routes. Keys are managed with the
+// apikey subcommand.
+type Auth struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+}
+
+// RateLimit limits the journal requests of every client to Requests per Window,
+// allowing bursts of Requests. Every IP is limited before authentication; with
+// KeyBy api_key every key is limited as well, after it, and IPRequests, when
+// set, is the limit of an IP, so that several keys can share one. Behind
+// reverse proxies, list their addresses or CIDR ranges in TrustedProxies so
+// that X-Forwarded-For is used. Backend is memory (a limit per replica) or resp
+// (one limit shared through the server).
+type RateLimit struct {
+	Enabled        bool          `yaml:"enabled" env-default:"false"`
+	Backend        string        `yaml:"backend" env-default:"memory"`
+	Requests       int           `yaml:"requests" env-default:"60"`
+	IPRequests     int           `yaml:"ip_requests"`
+	Window         time.Duration `yaml:"window" env-default:"1m"`
+	KeyBy          string        `yaml:"key_by" env-default:"ip"`
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+	RESP           RESP          `yaml:"resp"`
+}
+
+// Admin serves the /admin routes to admin API keys. FetchTimeout bounds
+// fetching a picture on demand, archiving its images included, and
+// BackfillChunkDays is the number of days a backfill requests at once.
+type Admin struct {
+	Enabled           bool          `yaml:"enabled" env-default:"true"`
+	FetchTimeout      time.Duration `yaml:"fetch_timeout" env-default:"2m"`
+	BackfillChunkDays int           `yaml:"backfill_chunk_days" env-default:"30"`
 }
 
 func MustLoad() *Config {
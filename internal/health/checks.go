@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/storage"
+	"time"
+)
+
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Database is down while the database does not answer pings.
+func Database(db Pinger) Check {
+	return func(ctx context.Context) Result {
+		start := time.Now()
+		err := db.PingContext(ctx)
+		details := map[string]any{"latency_ms": time.Since(start).Milliseconds()}
+		if err != nil {
+			return failed(StatusDown, err, details)
+		}
+
+		return Result{Status: StatusOK, Details: details}
+	}
+}
+
+type MigrationVersioner interface {
+	Version(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error)
+}
+
+// Migrations is down while the schema is behind the expected version or a
+// migration failed half way, and degraded when the schema is ahead of it, e.g.
+// after a rollback of the service.
+func Migrations(versioner MigrationVersioner, db *sql.DB, expected uint) Check {
+	return func(ctx context.Context) Result {
+		version, dirty, err := versioner.Version(ctx, db)
+		details := map[string]any{"version": version, "expected": expected, "dirty": dirty}
+
+		switch {
+		case err != nil:
+			return failed(StatusDown, err, details)
+		case dirty:
+			return failed(StatusDown, errors.New("a migration failed, the schema must be repaired"), details)
+		case version < expected:
+			return failed(StatusDown, errors.New("schema is behind"), details)
+		case version > expected:
+			return failed(StatusDegraded, errors.New("schema is ahead of the service"), details)
+		}
+
+		return Result{Status: StatusOK, Details: details}
+	}
+}
+
+type WorkerStatus interface {
+	LastSuccess() time.Time
+}
+
+// Worker is degraded when the worker has not fetched successfully for maxAge.
+// A worker that never succeeded is given maxAge from the creation of the check.
+func Worker(worker WorkerStatus, maxAge time.Duration, clk clock.Clock) Check {
+	startedAt := clk.Now()
+
+	return func(ctx context.Context) Result {
+		lastSuccess := worker.LastSuccess()
+		details := map[string]any{"last_success": nil}
+
+		since := startedAt
+		if !lastSuccess.IsZero() {
+			details["last_success"] = lastSuccess
+			since = lastSuccess
+		}
+
+		if age := clk.Now().Sub(since); age > maxAge {
+			return failed(StatusDegraded, fmt.Errorf("no successful fetch for %s", age.Round(time.Minute)), details)
+		}
+
+		return Result{Status: StatusOK, Details: details}
+	}
+}
+
+type LatestDateGetter interface {
+	GetLatestAPODDate(ctx context.Context, source string) (apod_date.Date, error)
+}
+
+// LatestEntry is degraded when the newest stored entry of the source is more
+// than maxAgeDays old or there is none, and down when it cannot be read.
+func LatestEntry(getter LatestDateGetter, source string, maxAgeDays int, clk clock.Clock) Check {
+	return func(ctx context.Context) Result {
+		details := map[string]any{"source": source}
+
+		latest, err := getter.GetLatestAPODDate(ctx, source)
+		if errors.Is(err, storage.ErrAPODNotFound) {
+			return failed(StatusDegraded, errors.New("no entries"), details)
+		}
+		if err != nil {
+			return failed(StatusDown, err, details)
+		}
+
+		age := latest.DaysUntil(apod_date.FromTime(clk.Now().In(apod_date.Location)))
+		details["date"], details["age_days"] = latest, age
+		if age > maxAgeDays {
+			return failed(StatusDegraded, fmt.Errorf("latest entry is %d days old", age), details)
+		}
+
+		return Result{Status: StatusOK, Details: details}
+	}
+}
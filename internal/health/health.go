@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Statuses ordered from best to worst.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+var severity = map[string]int{StatusOK: 0, StatusDegraded: 1, StatusDown: 2}
+
+// Result is the outcome of a check. Details are reported as they are.
+type Result struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Check inspects a dependency. It must return when ctx is done.
+type Check func(ctx context.Context) Result
+
+// Report is the result of every check and the worst of their statuses.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs named checks concurrently, each bounded by the timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  map[string]Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add registers a check, replacing the one with the same name.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Check runs every check and reports their results. It returns at the timeout
+// also when a check ignores its context; such a check is reported as down.
+func (c *Checker) Check(ctx context.Context) Report {
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type outcome struct {
+		i      int
+		result Result
+	}
+	// Buffered so that checks returning after the timeout do not leak.
+	outcomes := make(chan outcome, len(names))
+	for i, name := range names {
+		go func(i int, check Check) {
+			outcomes <- outcome{i: i, result: check(ctx)}
+		}(i, c.checks[name])
+	}
+
+	results := make([]Result, len(names))
+	done := make([]bool, len(names))
+wait:
+	for range names {
+		select {
+		case o := <-outcomes:
+			results[o.i], done[o.i] = o.result, true
+		case <-ctx.Done():
+			for i := range results {
+				if !done[i] {
+					results[i] = Result{Status: StatusDown, Error: ctx.Err().Error()}
+				}
+			}
+			break wait
+		}
+	}
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if severity[results[i].Status] > severity[report.Status] {
+			report.Status = results[i].Status
+		}
+	}
+
+	return report
+}
+
+func failed(status string, err error, details map[string]any) Result {
+	return Result{Status: status, Error: err.Error(), Details: details}
+}
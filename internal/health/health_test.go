@@ -0,0 +1,206 @@
+package health_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"stellar_journal/internal/health"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"stellar_journal/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pinger struct{ err error }
+
+func (p pinger) PingContext(context.Context) error { return p.err }
+
+type versioner struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (v versioner) Version(context.Context, *sql.DB) (uint, bool, error) {
+	return v.version, v.dirty, v.err
+}
+
+type worker struct{ lastSuccess time.Time }
+
+func (w worker) LastSuccess() time.Time { return w.lastSuccess }
+
+type latestGetter struct {
+	date apod_date.Date
+	err  error
+}
+
+func (g latestGetter) GetLatestAPODDate(context.Context, string) (apod_date.Date, error) {
+	return g.date, g.err
+}
+
+func check(status string) health.Check {
+	return func(context.Context) health.Result { return health.Result{Status: status} }
+}
+
+func TestCheckerReportsWorstStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		checks map[string]string
+		status string
+	}{
+		{name: "No Checks", checks: map[string]string{}, status: health.StatusOK},
+		{name: "OK", checks: map[string]string{"a": health.StatusOK, "b": health.StatusOK}, status: health.StatusOK},
+		{name: "Degraded", checks: map[string]string{"a": health.StatusDegraded, "b": health.StatusOK}, status: health.StatusDegraded},
+		{name: "Down", checks: map[string]string{"a": health.StatusDegraded, "b": health.StatusDown}, status: health.StatusDown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			checker := health.NewChecker(time.Second)
+			for name, status := range tc.checks {
+				checker.Add(name, check(status))
+			}
+
+			report := checker.Check(context.Background())
+			require.Equal(t, tc.status, report.Status)
+			require.Len(t, report.Checks, len(tc.checks))
+			for name, status := range tc.checks {
+				require.Equal(t, status, report.Checks[name].Status)
+			}
+		})
+	}
+}
+
+func TestCheckerBoundsChecksByTimeout(t *testing.T) {
+	checker := health.NewChecker(10 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) health.Result {
+		<-ctx.Done()
+		return health.Result{Status: health.StatusDown, Error: ctx.Err().Error()}
+	})
+
+	report := checker.Check(context.Background())
+	require.Equal(t, health.StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestCheckerReturnsAtTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	checker := health.NewChecker(10 * time.Millisecond)
+	checker.Add("stuck", func(context.Context) health.Result {
+		<-block
+		return health.Result{Status: health.StatusOK}
+	})
+	checker.Add("fast", check(health.StatusOK))
+
+	done := make(chan health.Report, 1)
+	go func() { done <- checker.Check(context.Background()) }()
+
+	select {
+	case report := <-done:
+		require.Equal(t, health.StatusDown, report.Status)
+		require.Equal(t, health.StatusDown, report.Checks["stuck"].Status, "a check ignoring its context is down")
+		require.Equal(t, health.StatusOK, report.Checks["fast"].Status)
+	case <-time.After(time.Second):
+		t.Fatal("Check waited for a check ignoring its context")
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	require.Equal(t, health.StatusOK, health.Database(pinger{})(context.Background()).Status)
+
+	res := health.Database(pinger{err: errors.New("connection refused")})(context.Background())
+	require.Equal(t, health.StatusDown, res.Status)
+	require.Equal(t, "connection refused", res.Error)
+}
+
+func TestMigrations(t *testing.T) {
+	cases := []struct {
+		name      string
+		versioner versioner
+		status    string
+	}{
+		{name: "Current", versioner: versioner{version: 8}, status: health.StatusOK},
+		{name: "Behind", versioner: versioner{version: 7}, status: health.StatusDown},
+		{name: "Ahead", versioner: versioner{version: 9}, status: health.StatusDegraded},
+		{name: "Dirty", versioner: versioner{version: 8, dirty: true}, status: health.StatusDown},
+		{name: "Error", versioner: versioner{err: errors.New("connection refused")}, status: health.StatusDown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := health.Migrations(tc.versioner, nil, 8)(context.Background())
+			require.Equal(t, tc.status, res.Status)
+		})
+	}
+}
+
+func TestWorker(t *testing.T) {
+	start := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		lastSuccess time.Time
+		elapsed     time.Duration
+		status      string
+	}{
+		{name: "Recent", lastSuccess: start, elapsed: time.Hour, status: health.StatusOK},
+		{name: "Stale", lastSuccess: start, elapsed: 25 * time.Hour, status: health.StatusDegraded},
+		{name: "Never Within Grace", elapsed: time.Hour, status: health.StatusOK},
+		{name: "Never", elapsed: 25 * time.Hour, status: health.StatusDegraded},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			clk := fakeclock.New(start)
+			c := health.Worker(worker{lastSuccess: tc.lastSuccess}, 24*time.Hour, clk)
+			clk.Advance(tc.elapsed)
+
+			require.Equal(t, tc.status, c(context.Background()).Status)
+		})
+	}
+}
+
+func TestLatestEntry(t *testing.T) {
+	// 2024-01-05 in the APOD time zone.
+	now := time.Date(2024, time.January, 5, 18, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		getter latestGetter
+		status string
+	}{
+		{name: "Today", getter: latestGetter{date: apod_date.MustParse("2024-01-05")}, status: health.StatusOK},
+		{name: "Within Max Age", getter: latestGetter{date: apod_date.MustParse("2024-01-03")}, status: health.StatusOK},
+		{name: "Stale", getter: latestGetter{date: apod_date.MustParse("2024-01-02")}, status: health.StatusDegraded},
+		{name: "No Entries", getter: latestGetter{err: storage.ErrAPODNotFound}, status: health.StatusDegraded},
+		{name: "Error", getter: latestGetter{err: errors.New("connection refused")}, status: health.StatusDown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := health.LatestEntry(tc.getter, "nasa_apod", 2, fakeclock.New(now))(context.Background())
+			require.Equal(t, tc.status, res.Status)
+		})
+	}
+}
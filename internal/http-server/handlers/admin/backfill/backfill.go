@@ -0,0 +1,157 @@
+package backfill
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/apod_backfill"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Job describes a backfill. FinishedAt and Stats are set once it finished.
+type Job struct {
+	From       apod_date.Date `json:"from"`
+	To         apod_date.Date `json:"to"`
+	State      string         `json:"state"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at"`
+	Stats      *Stats         `json:"stats"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type Stats struct {
+	Chunks        int `json:"chunks"`
+	SkippedChunks int `json:"skipped_chunks"`
+	Inserted      int `json:"inserted"`
+	Skipped       int `json:"skipped"`
+	NoPicture     int `json:"no_picture"`
+}
+
+type Response struct {
+	resp.Response
+	Data Job `json:"data"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Runner
+type Runner interface {
+	Start(from, to apod_date.Date) (apod_backfill.Job, error)
+	Job() (apod_backfill.Job, bool)
+}
+
+// Start starts a backfill of the NASA APOD days between the from and to
+// parameters in the background and answers 202 with the job. to defaults to
+// today. Only one backfill runs at a time.
+func Start(log *slog.Logger, runner Runner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.backfill.Start"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		today := apod_date.Today()
+		from, err := parseDate(r.URL.Query().Get("from"), today)
+		if err != nil {
+			log.Info("invalid from", sl.Err(err))
+
+			resp.RenderError(w, r, resp.InvalidParameter("from", err.Error()))
+
+			return
+		}
+
+		to := today
+		if param := r.URL.Query().Get("to"); param != "" {
+			to, err = parseDate(param, today)
+			if err != nil {
+				log.Info("invalid to", sl.Err(err))
+
+				resp.RenderError(w, r, resp.InvalidParameter("to", err.Error()))
+
+				return
+			}
+		}
+		if to.Before(from) {
+			resp.RenderError(w, r, resp.InvalidParameter("to", "to is before from"))
+
+			return
+		}
+
+		job, err := runner.Start(from, to)
+		if errors.Is(err, apod_backfill.ErrRunning) {
+			resp.RenderError(w, r, resp.Conflict("a backfill is running"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to start backfill", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to start backfill", err))
+
+			return
+		}
+
+		log.Info("backfill started", slog.String("from", from.String()), slog.String("to", to.String()))
+
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, Response{Response: resp.OK(), Data: newJob(job)})
+	}
+}
+
+// Status reports the running backfill, or the last one if none runs.
+func Status(runner Runner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		job, ok := runner.Job()
+		if !ok {
+			resp.RenderError(w, r, resp.NotFound("no backfill was started"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Data: newJob(job)})
+	}
+}
+
+func parseDate(param string, today apod_date.Date) (apod_date.Date, error) {
+	date, err := apod_date.Parse(param)
+	if err != nil {
+		return apod_date.Date{}, apod_date.ErrInvalidFormat
+	}
+	if err := date.Validate(today); err != nil {
+		return apod_date.Date{}, err
+	}
+
+	return date, nil
+}
+
+func newJob(job apod_backfill.Job) Job {
+	out := Job{
+		From:      job.From,
+		To:        job.To,
+		State:     job.Status,
+		StartedAt: job.StartedAt,
+		Error:     job.Error,
+	}
+	if job.Status != apod_backfill.JobRunning {
+		out.FinishedAt = &job.FinishedAt
+		out.Stats = &Stats{
+			Chunks:        job.Stats.Chunks,
+			SkippedChunks: job.Stats.SkippedChunks,
+			Inserted:      job.Stats.Inserted,
+			Skipped:       job.Stats.Skipped,
+			NoPicture:     job.Stats.NoPicture,
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,110 @@
+package backfill_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/apod_backfill"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/admin/backfill"
+	"stellar_journal/internal/http-server/handlers/admin/backfill/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestStartHandler(t *testing.T) {
+	from, to := apod_date.MustParse("2024-01-01"), apod_date.MustParse("2024-01-31")
+	startedAt := time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		query    string
+		startErr error
+		status   int
+		param    string
+	}{
+		{name: "Started", query: "?from=2024-01-01&to=2024-01-31", status: http.StatusAccepted},
+		{name: "Running", query: "?from=2024-01-01&to=2024-01-31", startErr: fmt.Errorf("error: %w", apod_backfill.ErrRunning), status: http.StatusConflict},
+		{name: "Missing From", query: "?to=2024-01-31", status: http.StatusBadRequest, param: "from"},
+		{name: "Before Epoch", query: "?from=1990-01-01&to=2024-01-31", status: http.StatusBadRequest, param: "from"},
+		{name: "Inverted Range", query: "?from=2024-01-31&to=2024-01-01", status: http.StatusBadRequest, param: "to"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := mocks.NewRunner(t)
+			if tc.param == "" {
+				runner.On("Start", from, to).Return(apod_backfill.Job{From: from, To: to, Status: apod_backfill.JobRunning, StartedAt: startedAt}, tc.startErr).Once()
+			}
+
+			rr := httptest.NewRecorder()
+			backfill.Start(slogdiscard.NewDiscardLogger(), runner).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/backfill"+tc.query, nil))
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.param != "" {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				require.Equal(t, tc.param, body.Error.Details["parameter"])
+				return
+			}
+			if tc.status != http.StatusAccepted {
+				return
+			}
+
+			var body backfill.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, from, body.Data.From)
+			require.Equal(t, apod_backfill.JobRunning, body.Data.State)
+			require.Nil(t, body.Data.FinishedAt)
+			require.Nil(t, body.Data.Stats)
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	startedAt := time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Finished", func(t *testing.T) {
+		runner := mocks.NewRunner(t)
+		runner.On("Job").Return(apod_backfill.Job{
+			From:       apod_date.MustParse("2024-01-01"),
+			To:         apod_date.MustParse("2024-01-31"),
+			Status:     apod_backfill.JobFailed,
+			StartedAt:  startedAt,
+			FinishedAt: startedAt.Add(time.Minute),
+			Stats:      apod_backfill.Stats{Chunks: 1, Inserted: 30},
+			Error:      "rate limited",
+		}, true).Once()
+
+		rr := httptest.NewRecorder()
+		backfill.Status(runner).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/backfill", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body backfill.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Equal(t, apod_backfill.JobFailed, body.Data.State)
+		require.Equal(t, 30, body.Data.Stats.Inserted)
+		require.True(t, startedAt.Add(time.Minute).Equal(*body.Data.FinishedAt))
+		require.Equal(t, "rate limited", body.Data.Error)
+	})
+
+	t.Run("None Started", func(t *testing.T) {
+		runner := mocks.NewRunner(t)
+		runner.On("Job").Return(apod_backfill.Job{}, false).Once()
+
+		rr := httptest.NewRecorder()
+		backfill.Status(runner).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/backfill", nil))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,188 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/http-server/handlers/journal/get/by_date"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/storage"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	Data stellar_journal_models.APOD `json:"data"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Fetcher
+type Fetcher interface {
+	Fetch(ctx context.Context, source string, date apod_date.Date, overwrite bool) (*stellar_journal_models.APOD, bool, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Deleter
+type Deleter interface {
+	DeleteAPOD(ctx context.Context, source string, date apod_date.Date) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CacheInvalidator
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context, apod *stellar_journal_models.APOD) error
+}
+
+// Fetch fetches the picture of a date from its source right away and saves it,
+// answering 201 with the new entry. A stored entry is only replaced when the
+// overwrite parameter is true, and the request conflicts otherwise. Fetching
+// and archiving the images may take up to timeout, also when that is longer
+// than the write timeout of the server.
+func Fetch(log *slog.Logger, fetcher Fetcher, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.journal.Fetch"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		source, date, ok := parseEntry(w, r, log)
+		if !ok {
+			return
+		}
+
+		overwrite := false
+		if param := r.URL.Query().Get("overwrite"); param != "" {
+			var err error
+			overwrite, err = strconv.ParseBool(param)
+			if err != nil {
+				log.Info("invalid overwrite", sl.Err(err))
+
+				resp.RenderError(w, r, resp.InvalidParameter("overwrite", "invalid overwrite, expected true or false"))
+
+				return
+			}
+		}
+
+		ctx := r.Context()
+		if timeout > 0 {
+			// Recorders in tests do not support deadlines; the server does.
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout))
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		apod, created, err := fetcher.Fetch(ctx, source, date, overwrite)
+		switch {
+		case errors.Is(err, apod_worker.ErrUnknownSource):
+			resp.RenderError(w, r, resp.InvalidParameter("source", "source is not enabled"))
+			return
+		case errors.Is(err, storage.ErrAPODExists):
+			resp.RenderError(w, r, resp.Conflict("entry exists, set overwrite to replace it"))
+			return
+		case errors.Is(err, providers.ErrNoPicture):
+			resp.RenderError(w, r, resp.NotFound("no picture for the date"))
+			return
+		case errors.Is(err, providers.ErrNotPublishedYet):
+			resp.RenderError(w, r, resp.NotFound("picture not published yet"))
+			return
+		case err != nil:
+			log.Error("failed to fetch apod", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to fetch apod", err))
+
+			return
+		}
+
+		log.Info("apod fetched", slog.String("source", source), slog.String("date", date.String()), slog.Bool("created", created))
+
+		if created {
+			render.Status(r, http.StatusCreated)
+		}
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Data:     *apod,
+		})
+	}
+}
+
+// Delete deletes the entry of a date and drops it from the read cache unless
+// cache is nil. Archived image files are kept.
+func Delete(log *slog.Logger, deleter Deleter, cache CacheInvalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.journal.Delete"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		source, date, ok := parseEntry(w, r, log)
+		if !ok {
+			return
+		}
+
+		err := deleter.DeleteAPOD(r.Context(), source, date)
+		if errors.Is(err, storage.ErrAPODNotFound) {
+			log.Info("apod not found", sl.Err(err))
+
+			resp.RenderError(w, r, resp.NotFound("apod not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete apod", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to delete apod", err))
+
+			return
+		}
+
+		log.Info("apod deleted", slog.String("source", source), slog.String("date", date.String()))
+
+		if cache != nil {
+			if err := cache.Invalidate(r.Context(), &stellar_journal_models.APOD{Source: source, Date: date}); err != nil {
+				log.Error("failed to invalidate cached reads", sl.Err(err))
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// parseEntry parses the date and source of the entry a request is about and
+// renders the error if one is invalid.
+func parseEntry(w http.ResponseWriter, r *http.Request, log *slog.Logger) (string, apod_date.Date, bool) {
+	source, err := by_date.ParseSource(r.URL.Query().Get("source"))
+	if err != nil {
+		log.Info("invalid source", sl.Err(err))
+
+		resp.RenderError(w, r, resp.InvalidParameter("source", err.Error()))
+
+		return "", apod_date.Date{}, false
+	}
+
+	date, err := by_date.ParseDate(chi.URLParam(r, "date"), apod_date.Today())
+	if err != nil {
+		log.Info("invalid date", sl.Err(err))
+
+		resp.RenderError(w, r, resp.InvalidParameter("date", err.Error()))
+
+		return "", apod_date.Date{}, false
+	}
+
+	return source, date, true
+}
@@ -0,0 +1,134 @@
+package journal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/apod_worker"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/admin/journal"
+	"stellar_journal/internal/http-server/handlers/admin/journal/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestFetchHandler(t *testing.T) {
+	date := apod_date.MustParse("2024-01-02")
+
+	cases := []struct {
+		name      string
+		query     string
+		source    string
+		overwrite bool
+		created   bool
+		fetchErr  error
+		status    int
+		code      string
+	}{
+		{name: "Created", created: true, status: http.StatusCreated},
+		{name: "Overwritten", query: "?overwrite=true", overwrite: true, status: http.StatusOK},
+		{name: "Other Source", query: "?source=bing", source: stellar_journal_models.SourceBing, created: true, status: http.StatusCreated},
+		{name: "Exists", fetchErr: fmt.Errorf("error: %w", storage.ErrAPODExists), status: http.StatusConflict, code: resp.CodeConflict},
+		{name: "Source Not Enabled", query: "?source=bing", source: stellar_journal_models.SourceBing, fetchErr: apod_worker.ErrUnknownSource, status: http.StatusBadRequest, code: resp.CodeInvalidParameter},
+		{name: "No Picture", fetchErr: providers.ErrNoPicture, status: http.StatusNotFound, code: resp.CodeNotFound},
+		{name: "Fetch Fails", fetchErr: errors.New("connection refused"), status: http.StatusInternalServerError, code: resp.CodeInternal},
+		{name: "Invalid Overwrite", query: "?overwrite=maybe", status: http.StatusBadRequest, code: resp.CodeInvalidParameter},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := tc.source
+			if source == "" {
+				source = stellar_journal_models.SourceNASAAPOD
+			}
+
+			fetcher := mocks.NewFetcher(t)
+			if tc.code != resp.CodeInvalidParameter || tc.fetchErr != nil {
+				var apod *stellar_journal_models.APOD
+				if tc.fetchErr == nil {
+					apod = &stellar_journal_models.APOD{Source: source, Date: date, Title: "Galaxy"}
+				}
+				fetcher.On("Fetch", mock.Anything, source, date, tc.overwrite).Return(apod, tc.created, tc.fetchErr).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/admin/journal/{date}/fetch", journal.Fetch(slogdiscard.NewDiscardLogger(), fetcher, time.Minute))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/journal/2024-01-02/fetch"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			if tc.code != "" {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				require.Equal(t, tc.code, body.Error.Code)
+				return
+			}
+
+			var body journal.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, "Galaxy", body.Data.Title)
+			require.Equal(t, source, body.Data.Source)
+		})
+	}
+}
+
+func TestDeleteHandler(t *testing.T) {
+	date := apod_date.MustParse("2024-01-02")
+
+	cases := []struct {
+		name      string
+		url       string
+		deleteErr error
+		status    int
+	}{
+		{name: "Deleted", url: "/admin/journal/2024-01-02", status: http.StatusNoContent},
+		{name: "Not Found", url: "/admin/journal/2024-01-02", deleteErr: fmt.Errorf("error: %w", storage.ErrAPODNotFound), status: http.StatusNotFound},
+		{name: "Delete Fails", url: "/admin/journal/2024-01-02", deleteErr: errors.New("connection refused"), status: http.StatusInternalServerError},
+		{name: "Invalid Date", url: "/admin/journal/1990-01-01", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deleter := mocks.NewDeleter(t)
+			cache := mocks.NewCacheInvalidator(t)
+			if tc.status != http.StatusBadRequest {
+				deleter.On("DeleteAPOD", mock.Anything, stellar_journal_models.SourceNASAAPOD, date).Return(tc.deleteErr).Once()
+			}
+			if tc.status == http.StatusNoContent {
+				cache.On("Invalidate", mock.Anything, &stellar_journal_models.APOD{Source: stellar_journal_models.SourceNASAAPOD, Date: date}).Return(nil).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Delete("/admin/journal/{date}", journal.Delete(slogdiscard.NewDiscardLogger(), deleter, cache))
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, tc.url, nil))
+
+			require.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
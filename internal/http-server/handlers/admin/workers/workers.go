@@ -0,0 +1,71 @@
+package workers
+
+import (
+	"net/http"
+	"slices"
+	"stellar_journal/internal/apod_worker"
+	resp "stellar_journal/internal/lib/api/response"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// Worker is the state of the worker of a source. Times are null until they are
+// known; NextRun is null while a run is in progress.
+type Worker struct {
+	Source      string     `json:"source"`
+	LastRun     *time.Time `json:"last_run"`
+	LastSuccess *time.Time `json:"last_success"`
+	LastError   *string    `json:"last_error"`
+	NextRun     *time.Time `json:"next_run"`
+	Successes   uint64     `json:"successes"`
+	Failures    uint64     `json:"failures"`
+}
+
+type Response struct {
+	resp.Response
+	Data []Worker `json:"data"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StatsGetter
+type StatsGetter interface {
+	Stats() map[string]apod_worker.Stats
+}
+
+// New lists the workers of the enabled sources ordered by source.
+func New(workers StatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := workers.Stats()
+
+		data := make([]Worker, 0, len(stats))
+		for source, s := range stats {
+			worker := Worker{
+				Source:      source,
+				LastRun:     timeOrNil(s.LastRun),
+				LastSuccess: timeOrNil(s.LastSuccess),
+				NextRun:     timeOrNil(s.NextRun),
+				Successes:   s.Successes,
+				Failures:    s.Failures,
+			}
+			if s.LastError != "" {
+				worker.LastError = &s.LastError
+			}
+			data = append(data, worker)
+		}
+		slices.SortFunc(data, func(a, b Worker) int {
+			return strings.Compare(a.Source, b.Source)
+		})
+
+		w.Header().Set("Cache-Control", "no-store")
+		render.JSON(w, r, Response{Response: resp.OK(), Data: data})
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,55 @@
+package workers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/admin/workers"
+	"stellar_journal/internal/http-server/handlers/admin/workers/mocks"
+)
+
+func TestWorkersHandler(t *testing.T) {
+	lastRun := time.Date(2024, time.January, 2, 5, 5, 0, 0, time.UTC)
+
+	getter := mocks.NewStatsGetter(t)
+	getter.On("Stats").Return(map[string]apod_worker.Stats{
+		stellar_journal_models.SourceNASAAPOD: {
+			Successes:   3,
+			Failures:    1,
+			LastRun:     lastRun,
+			LastSuccess: lastRun.Add(-24 * time.Hour),
+			LastError:   "connection refused",
+			NextRun:     lastRun.Add(5 * time.Minute),
+		},
+		stellar_journal_models.SourceBing: {},
+	}).Once()
+
+	rr := httptest.NewRecorder()
+	workers.New(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/workers", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	var body workers.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Len(t, body.Data, 2)
+
+	bing, nasa := body.Data[0], body.Data[1]
+	require.Equal(t, stellar_journal_models.SourceBing, bing.Source)
+	require.Nil(t, bing.LastRun)
+	require.Nil(t, bing.NextRun)
+	require.Nil(t, bing.LastError)
+
+	require.Equal(t, stellar_journal_models.SourceNASAAPOD, nasa.Source)
+	require.True(t, lastRun.Equal(*nasa.LastRun))
+	require.True(t, lastRun.Add(5*time.Minute).Equal(*nasa.NextRun))
+	require.Equal(t, "connection refused", *nasa.LastError)
+	require.Equal(t, uint64(3), nasa.Successes)
+}
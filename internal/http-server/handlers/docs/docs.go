@@ -0,0 +1,43 @@
+package docs
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// Spec serves the OpenAPI document, encoded as JSON.
+func Spec(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	}
+}
+
+var swaggerUI = template.Must(template.New("swagger_ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>Stellar Journal API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({url: "{{.}}", dom_id: "#swagger-ui"});
+		};
+	</script>
+</body>
+</html>
+`))
+
+// SwaggerUI serves a Swagger UI page rendering the OpenAPI document at specURL.
+// The UI itself is loaded from a CDN.
+func SwaggerUI(specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = swaggerUI.Execute(w, specURL)
+	}
+}
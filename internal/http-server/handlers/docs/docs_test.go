@@ -0,0 +1,27 @@
+package docs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/http-server/handlers/docs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec(t *testing.T) {
+	rr := httptest.NewRecorder()
+	docs.Spec([]byte(`{"openapi":"3.0.3"}`)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"openapi":"3.0.3"}`, rr.Body.String())
+}
+
+func TestSwaggerUI(t *testing.T) {
+	rr := httptest.NewRecorder()
+	docs.SwaggerUI("/openapi.json").ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `url: "\/openapi.json"`)
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/health"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Checker
+type Checker interface {
+	Check(ctx context.Context) health.Report
+}
+
+// Live reports that the process serves requests. It checks no dependencies, so
+// a failing database never gets the process restarted.
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		render.JSON(w, r, health.Report{Status: health.StatusOK, Checks: map[string]health.Result{}})
+	}
+}
+
+// Ready reports the status of every dependency. It answers 503 while a
+// dependency is down and 200 otherwise, including when the service is degraded.
+func Ready(log *slog.Logger, checker Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.Ready"
+
+		report := checker.Check(r.Context())
+		if report.Status != health.StatusOK {
+			log.Warn("service is not healthy",
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("status", report.Status),
+				slog.Any("checks", report.Checks),
+			)
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == health.StatusDown {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, report)
+	}
+}
@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/health"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	handler "stellar_journal/internal/http-server/handlers/health"
+	"stellar_journal/internal/http-server/handlers/health/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestReadyHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		report health.Report
+		status int
+	}{
+		{
+			name: "OK",
+			report: health.Report{Status: health.StatusOK, Checks: map[string]health.Result{
+				"database": {Status: health.StatusOK},
+			}},
+			status: http.StatusOK,
+		},
+		{
+			name: "Degraded",
+			report: health.Report{Status: health.StatusDegraded, Checks: map[string]health.Result{
+				"database":    {Status: health.StatusOK},
+				"latest_apod": {Status: health.StatusDegraded, Error: "latest entry is 3 days old"},
+			}},
+			status: http.StatusOK,
+		},
+		{
+			name: "Down",
+			report: health.Report{Status: health.StatusDown, Checks: map[string]health.Result{
+				"database": {Status: health.StatusDown, Error: "connection refused"},
+			}},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			checkerMock := mocks.NewChecker(t)
+			checkerMock.On("Check", mock.Anything).Return(tc.report).Once()
+
+			req, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.Ready(slogdiscard.NewDiscardLogger(), checkerMock).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			var resp health.Report
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.report, resp)
+		})
+	}
+}
+
+func TestLiveHandler(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Live().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp health.Report
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, health.StatusOK, resp.Status)
+}
@@ -1,50 +1,168 @@
 package all
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"stellar_journal/internal/lib/api/http_cache"
 	resp "stellar_journal/internal/lib/api/response"
 	"stellar_journal/internal/lib/logger/sl"
 	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
 )
 
 type Response struct {
 	resp.Response
-	Data []stellar_journal_models.APOD `json:"data"`
+	Data       []stellar_journal_models.APOD `json:"data"`
+	Total      int                           `json:"total"`
+	NextCursor string                        `json:"next_cursor,omitempty"`
+	PrevCursor string                        `json:"prev_cursor,omitempty"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=JournalGetter
 type JournalGetter interface {
-	GetJournal() (*[]stellar_journal_models.APOD, error)
+	GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error)
 }
 
-func New(log *slog.Logger, journalGetter JournalGetter) http.HandlerFunc {
+// New serves a page of the journal. Pages grow and change daily, so they are
+// cached for the recent max age of cachePolicy and revalidated by ETag and
+// Last-Modified.
+func New(log *slog.Logger, journalGetter JournalGetter, cachePolicy http_cache.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.journal.get.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		journals, err := journalGetter.GetJournal()
+		query, err := parseQuery(r.URL.Query())
+		if err != nil {
+			log.Info("invalid journal query", sl.Err(err))
+
+			resp.RenderError(w, r, err)
+
+			return
+		}
+
+		page, err := journalGetter.GetJournal(r.Context(), query)
 		if err != nil {
 			log.Error("failed to get journals", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to get journals"))
+			resp.RenderError(w, r, resp.Internal("failed to get journals", err))
 
 			return
 		}
 
-		responseOK(w, r, *journals)
+		response := Response{
+			Response:   resp.OK(),
+			Data:       page.APODs,
+			Total:      page.Total,
+			NextCursor: page.NextCursor.String(),
+			PrevCursor: page.PrevCursor.String(),
+		}
+
+		etag, err := http_cache.ETag(response)
+		if err != nil {
+			log.Error("failed to compute etag", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to get journals", err))
+
+			return
+		}
+
+		w.Header().Set("Cache-Control", cachePolicy.Recent())
+		if http_cache.NotModified(w, r, etag, lastModified(page.APODs)) {
+			return
+		}
+
+		render.JSON(w, r, response)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, data []stellar_journal_models.APOD) {
-	render.JSON(w, r, Response{
-		Response: resp.OK(),
-		Data:     data,
-	})
+func parseQuery(values url.Values) (storage.JournalQuery, error) {
+	query := storage.JournalQuery{
+		Limit: DefaultLimit,
+		Order: storage.OrderDesc,
+		JournalFilter: storage.JournalFilter{
+			Source:         values.Get("source"),
+			MediaType:      values.Get("media_type"),
+			Copyright:      values.Get("copyright"),
+			ServiceVersion: values.Get("service_version"),
+		},
+	}
+
+	if query.Source != "" && !stellar_journal_models.IsSource(query.Source) {
+		return query, resp.InvalidParameter("source", "unknown source")
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return query, resp.InvalidParameter("limit", "invalid limit")
+		}
+		query.Limit = min(n, MaxLimit)
+	}
+
+	switch order := values.Get("order"); order {
+	case "":
+	case storage.OrderAsc, storage.OrderDesc:
+		query.Order = order
+	default:
+		return query, resp.InvalidParameter("order", "invalid order, expected asc or desc")
+	}
+
+	if values.Get("after") != "" && values.Get("before") != "" {
+		return query, resp.InvalidParameter("before", "after and before cannot be used together")
+	}
+	var err error
+	if query.After, err = parseCursor("after", values.Get("after")); err != nil {
+		return query, err
+	}
+	if query.Before, err = parseCursor("before", values.Get("before")); err != nil {
+		return query, err
+	}
+
+	if err := query.From.UnmarshalText([]byte(values.Get("from"))); err != nil {
+		return query, resp.InvalidParameter("from", "invalid from date, expected YYYY-MM-DD")
+	}
+	if err := query.To.UnmarshalText([]byte(values.Get("to"))); err != nil {
+		return query, resp.InvalidParameter("to", "invalid to date, expected YYYY-MM-DD")
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return query, resp.InvalidParameter("to", "from date is after to date")
+	}
+
+	return query, nil
+}
+
+func parseCursor(param, value string) (storage.Cursor, error) {
+	cursor, err := storage.ParseCursor(value)
+	if err != nil || cursor.Source != "" && !stellar_journal_models.IsSource(cursor.Source) {
+		return storage.Cursor{}, resp.InvalidParameter(param, "invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// lastModified returns when the most recently changed of apods changed.
+func lastModified(apods []stellar_journal_models.APOD) time.Time {
+	var latest time.Time
+	for _, apod := range apods {
+		if apod.UpdatedAt.After(latest) {
+			latest = apod.UpdatedAt
+		}
+	}
+
+	return latest
 }
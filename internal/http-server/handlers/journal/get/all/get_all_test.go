@@ -5,9 +5,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/apod_date"
 	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"stellar_journal/internal/http-server/handlers/journal/get/all"
@@ -17,18 +22,127 @@ import (
 
 func TestGetAllHandler(t *testing.T) {
 	cases := []struct {
-		name      string
-		respError string
-		mockError error
+		name       string
+		url        string
+		query      *storage.JournalQuery
+		page       *storage.JournalPage
+		respError  string
+		status     int
+		mockError  error
+		nextCursor string
 	}{
 		{
-			name: "Success",
+			name:   "Success",
+			url:    "/journal",
+			query:  &storage.JournalQuery{Limit: all.DefaultLimit, Order: storage.OrderDesc},
+			page:   &storage.JournalPage{APODs: []stellar_journal_models.APOD{}},
+			status: http.StatusOK,
+		},
+		{
+			name:  "Pagination",
+			url:   "/journal?limit=2&order=asc&after=2022-01-01",
+			query: &storage.JournalQuery{Limit: 2, Order: storage.OrderAsc, After: storage.Cursor{Date: apod_date.MustParse("2022-01-01")}},
+			page: &storage.JournalPage{
+				APODs:      []stellar_journal_models.APOD{{Date: apod_date.MustParse("2022-01-02")}, {Date: apod_date.MustParse("2022-01-03")}},
+				Total:      10,
+				NextCursor: storage.Cursor{Date: apod_date.MustParse("2022-01-03"), Source: stellar_journal_models.SourceNASAAPOD},
+				PrevCursor: storage.Cursor{Date: apod_date.MustParse("2022-01-02"), Source: stellar_journal_models.SourceNASAAPOD},
+			},
+			status:     http.StatusOK,
+			nextCursor: "2022-01-03.nasa_apod",
+		},
+		{
+			name: "Source",
+			url:  "/journal?source=bing&before=2022-01-03.bing",
+			query: &storage.JournalQuery{
+				Limit:         all.DefaultLimit,
+				Order:         storage.OrderDesc,
+				Before:        storage.Cursor{Date: apod_date.MustParse("2022-01-03"), Source: stellar_journal_models.SourceBing},
+				JournalFilter: storage.JournalFilter{Source: stellar_journal_models.SourceBing},
+			},
+			page:   &storage.JournalPage{APODs: []stellar_journal_models.APOD{{Source: stellar_journal_models.SourceBing}}, Total: 1},
+			status: http.StatusOK,
+		},
+		{
+			name: "Filters",
+			url:  "/journal?from=2021-03-01&to=2021-03-31&media_type=video&copyright=Jane+Doe&service_version=v1",
+			query: &storage.JournalQuery{
+				Limit: all.DefaultLimit,
+				Order: storage.OrderDesc,
+				JournalFilter: storage.JournalFilter{
+					From:           apod_date.MustParse("2021-03-01"),
+					To:             apod_date.MustParse("2021-03-31"),
+					MediaType:      "video",
+					Copyright:      "Jane Doe",
+					ServiceVersion: "v1",
+				},
+			},
+			page:   &storage.JournalPage{APODs: []stellar_journal_models.APOD{{MediaType: "video"}}, Total: 1},
+			status: http.StatusOK,
+		},
+		{
+			name:   "Limit Clamped",
+			url:    "/journal?limit=100000",
+			query:  &storage.JournalQuery{Limit: all.MaxLimit, Order: storage.OrderDesc},
+			page:   &storage.JournalPage{},
+			status: http.StatusOK,
 		},
 		{
 			name:      "GetJournal Error",
+			url:       "/journal",
+			query:     &storage.JournalQuery{Limit: all.DefaultLimit, Order: storage.OrderDesc},
 			respError: "failed to get journals",
+			status:    http.StatusInternalServerError,
 			mockError: errors.New("failed to get journals"),
 		},
+		{
+			name:      "Invalid Limit",
+			url:       "/journal?limit=abc",
+			respError: "invalid limit",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid Order",
+			url:       "/journal?order=up",
+			respError: "invalid order, expected asc or desc",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid Cursor",
+			url:       "/journal?after=yesterday",
+			respError: "invalid cursor",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Unknown Cursor Source",
+			url:       "/journal?after=2022-01-01.flickr",
+			respError: "invalid cursor",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Unknown Source",
+			url:       "/journal?source=flickr",
+			respError: "unknown source",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid From",
+			url:       "/journal?from=03-01-2021",
+			respError: "invalid from date, expected YYYY-MM-DD",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Inverted Range",
+			url:       "/journal?from=2021-03-31&to=2021-03-01",
+			respError: "from date is after to date",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Both Cursors",
+			url:       "/journal?after=2022-01-01&before=2022-02-01",
+			respError: "after and before cannot be used together",
+			status:    http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range cases {
@@ -39,25 +153,25 @@ func TestGetAllHandler(t *testing.T) {
 
 			apodGetterMock := mocks.NewJournalGetter(t)
 
-			if tc.mockError != nil {
-				apodGetterMock.On("GetJournal").
-					Return(nil, tc.mockError).
-					Once()
-			} else {
-				apodGetterMock.On("GetJournal").
-					Return(&[]stellar_journal_models.APOD{}, nil).
+			if tc.query != nil {
+				apodGetterMock.On("GetJournal", mock.Anything, *tc.query).
+					Return(tc.page, tc.mockError).
 					Once()
 			}
 
-			handler := all.New(slogdiscard.NewDiscardLogger(), apodGetterMock)
+			handler := all.New(slogdiscard.NewDiscardLogger(), apodGetterMock, http_cache.Policy{MaxAge: time.Hour, RecentMaxAge: time.Minute})
 
-			req, err := http.NewRequest(http.MethodGet, "/journal", nil)
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
-			require.Equal(t, rr.Code, http.StatusOK)
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status == http.StatusOK {
+				require.Equal(t, "public, max-age=60", rr.Header().Get("Cache-Control"))
+				require.NotEmpty(t, rr.Header().Get("ETag"))
+			}
 
 			body := rr.Body.String()
 
@@ -65,7 +179,16 @@ func TestGetAllHandler(t *testing.T) {
 
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 
-			require.Equal(t, tc.respError, resp.Error)
+			var respError string
+			if resp.Error != nil {
+				respError = resp.Error.Message
+			}
+			require.Equal(t, tc.respError, respError)
+			require.Equal(t, tc.nextCursor, resp.NextCursor)
+			if tc.page != nil {
+				require.Equal(t, tc.page.Total, resp.Total)
+				require.Len(t, resp.Data, len(tc.page.APODs))
+			}
 		})
 	}
 }
@@ -3,9 +3,11 @@
 package mocks
 
 import (
-	stellar_journal_models "stellar_journal/internal/models/stellar_journal_models"
+	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	storage "stellar_journal/internal/storage"
 )
 
 // JournalGetter is an autogenerated mock type for the JournalGetter type
@@ -13,25 +15,25 @@ type JournalGetter struct {
 	mock.Mock
 }
 
-// GetJournal provides a mock function with given fields:
-func (_m *JournalGetter) GetJournal() (*[]stellar_journal_models.APOD, error) {
-	ret := _m.Called()
+// GetJournal provides a mock function with given fields: ctx, query
+func (_m *JournalGetter) GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error) {
+	ret := _m.Called(ctx, query)
 
-	var r0 *[]stellar_journal_models.APOD
+	var r0 *storage.JournalPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*[]stellar_journal_models.APOD, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, storage.JournalQuery) (*storage.JournalPage, error)); ok {
+		return rf(ctx, query)
 	}
-	if rf, ok := ret.Get(0).(func() *[]stellar_journal_models.APOD); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, storage.JournalQuery) *storage.JournalPage); ok {
+		r0 = rf(ctx, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*[]stellar_journal_models.APOD)
+			r0 = ret.Get(0).(*storage.JournalPage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, storage.JournalQuery) error); ok {
+		r1 = rf(ctx, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,18 +1,30 @@
 package by_date
 
 import (
+	"context"
 	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
+	"stellar_journal/internal/lib/api/http_cache"
 	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
 	"stellar_journal/internal/lib/logger/sl"
 	"stellar_journal/internal/models/stellar_journal_models"
 	"stellar_journal/internal/storage"
 )
 
+// Date aliases accepted in place of YYYY-MM-DD.
+const (
+	AliasToday     = "today"
+	AliasYesterday = "yesterday"
+	AliasRandom    = "random"
+)
+
+var ErrUnknownSource = errors.New("unknown source")
+
 type Response struct {
 	resp.Response
 	Data stellar_journal_models.APOD `json:"data"`
@@ -20,45 +32,123 @@ type Response struct {
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APODByDateGetter
 type APODByDateGetter interface {
-	GetAPOD(date string) (*stellar_journal_models.APOD, error)
+	GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error)
+	GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error)
 }
 
-func New(log *slog.Logger, apodGetter APODByDateGetter) http.HandlerFunc {
+// New serves the entry of a date. Responses carry an ETag and Last-Modified for
+// conditional requests and a Cache-Control chosen by cachePolicy; random entries
+// are never cached.
+func New(log *slog.Logger, apodGetter APODByDateGetter, cachePolicy http_cache.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.journal.get.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		date := chi.URLParam(r, "date")
+		source, err := ParseSource(r.URL.Query().Get("source"))
+		if err != nil {
+			log.Info("invalid source", sl.Err(err))
+
+			resp.RenderError(w, r, resp.InvalidParameter("source", err.Error()))
+
+			return
+		}
 
-		apod, err := apodGetter.GetAPOD(date)
+		var apod *stellar_journal_models.APOD
+		var cacheControl string
+
+		if param := chi.URLParam(r, "date"); param == AliasRandom {
+			apod, err = apodGetter.GetRandomAPOD(r.Context(), source)
+			cacheControl = http_cache.NoStore
+		} else {
+			today := apod_date.Today()
+			date, parseErr := ParseDate(param, today)
+			if parseErr != nil {
+				log.Info("invalid date", sl.Err(parseErr))
+
+				resp.RenderError(w, r, resp.InvalidParameter("date", parseErr.Error()))
+
+				return
+			}
+
+			apod, err = apodGetter.GetAPOD(r.Context(), source, date)
+			cacheControl = cachePolicy.ForDate(date, today)
+			if param == AliasToday || param == AliasYesterday {
+				cacheControl = cachePolicy.Recent()
+			}
+		}
 		if errors.Is(err, storage.ErrAPODNotFound) {
-			log.Error("apod not found", sl.Err(err))
+			log.Info("apod not found", sl.Err(err))
 
-			w.WriteHeader(http.StatusNotFound)
-			render.JSON(w, r, resp.Error("apod not found"))
+			resp.RenderError(w, r, resp.NotFound("apod not found"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to get apod", sl.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("failed to get apod"))
+			resp.RenderError(w, r, resp.Internal("failed to get apod", err))
 
 			return
 		}
 
-		responseOK(w, r, *apod)
+		response := Response{
+			Response: resp.OK(),
+			Data:     *apod,
+		}
+
+		w.Header().Set("Cache-Control", cacheControl)
+		if cacheControl != http_cache.NoStore {
+			etag, err := http_cache.ETag(response)
+			if err != nil {
+				log.Error("failed to compute etag", sl.Err(err))
+
+				resp.RenderError(w, r, resp.Internal("failed to get apod", err))
+
+				return
+			}
+			if http_cache.NotModified(w, r, etag, apod.UpdatedAt) {
+				return
+			}
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// ParseSource validates the source query parameter. Entries of NASA APOD are
+// served when it is empty.
+func ParseSource(param string) (string, error) {
+	if param == "" {
+		return stellar_journal_models.SourceNASAAPOD, nil
 	}
+	if !stellar_journal_models.IsSource(param) {
+		return "", ErrUnknownSource
+	}
+
+	return param, nil
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, data stellar_journal_models.APOD) {
-	render.JSON(w, r, Response{
-		Response: resp.OK(),
-		Data:     data,
-	})
+// ParseDate resolves the today and yesterday aliases and validates that an APOD
+// can exist for the date.
+func ParseDate(param string, today apod_date.Date) (apod_date.Date, error) {
+	switch param {
+	case AliasToday:
+		return today, nil
+	case AliasYesterday:
+		return today.AddDays(-1), nil
+	}
+
+	date, err := apod_date.Parse(param)
+	if err != nil {
+		return apod_date.Date{}, apod_date.ErrInvalidFormat
+	}
+	if err := date.Validate(today); err != nil {
+		return apod_date.Date{}, err
+	}
+
+	return date, nil
 }
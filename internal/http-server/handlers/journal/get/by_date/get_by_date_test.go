@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/apod_date"
 	"stellar_journal/internal/models/stellar_journal_models"
 	"stellar_journal/internal/storage"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/mock"
@@ -19,22 +22,61 @@ import (
 	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
 )
 
+var cachePolicy = http_cache.Policy{MaxAge: time.Hour, RecentMaxAge: time.Minute}
+
 func TestGetByDateHandler(t *testing.T) {
 	cases := []struct {
-		name      string
-		date      string
-		respError string
-		status    int
-		mockError error
+		name         string
+		date         string
+		query        string
+		source       string
+		getter       string
+		respError    string
+		status       int
+		mockError    error
+		cacheControl string
 	}{
 		{
-			name:   "Success",
-			date:   "2022-01-01",
-			status: http.StatusOK,
+			name:         "Success",
+			date:         "2022-01-01",
+			getter:       "GetAPOD",
+			status:       http.StatusOK,
+			cacheControl: "public, max-age=3600",
+		},
+		{
+			name:         "Other Source",
+			date:         "2022-01-01",
+			query:        "?source=wikimedia",
+			source:       stellar_journal_models.SourceWikimedia,
+			getter:       "GetAPOD",
+			status:       http.StatusOK,
+			cacheControl: "public, max-age=3600",
+		},
+		{
+			name:      "Unknown Source",
+			date:      "2022-01-01",
+			query:     "?source=flickr",
+			respError: by_date.ErrUnknownSource.Error(),
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:         "Today",
+			date:         by_date.AliasToday,
+			getter:       "GetAPOD",
+			status:       http.StatusOK,
+			cacheControl: "public, max-age=60",
+		},
+		{
+			name:         "Random",
+			date:         by_date.AliasRandom,
+			getter:       "GetRandomAPOD",
+			status:       http.StatusOK,
+			cacheControl: "no-store",
 		},
 		{
 			name:      "GetAPOD Error",
 			date:      "2022-01-01",
+			getter:    "GetAPOD",
 			respError: "failed to get apod",
 			status:    http.StatusInternalServerError,
 			mockError: errors.New("failed to get apod"),
@@ -42,10 +84,29 @@ func TestGetByDateHandler(t *testing.T) {
 		{
 			name:      "APOD Not Found",
 			date:      "2022-01-01",
+			getter:    "GetAPOD",
 			respError: "apod not found",
 			status:    http.StatusNotFound,
 			mockError: storage.ErrAPODNotFound,
 		},
+		{
+			name:      "Invalid Format",
+			date:      "01-01-2022",
+			respError: apod_date.ErrInvalidFormat.Error(),
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Before Epoch",
+			date:      "1995-06-15",
+			respError: "date is before the first APOD (1995-06-16)",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "In Future",
+			date:      "2999-01-01",
+			respError: apod_date.ErrInFuture.Error(),
+			status:    http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range cases {
@@ -56,22 +117,28 @@ func TestGetByDateHandler(t *testing.T) {
 
 			apodGetterMock := mocks.NewAPODByDateGetter(t)
 
-			if tc.mockError != nil {
-				apodGetterMock.On("GetAPOD", mock.Anything).
-					Return(nil, tc.mockError).
+			source := tc.source
+			if source == "" {
+				source = stellar_journal_models.SourceNASAAPOD
+			}
+
+			switch tc.getter {
+			case "GetAPOD":
+				apodGetterMock.On("GetAPOD", mock.Anything, source, mock.AnythingOfType("apod_date.Date")).
+					Return(&stellar_journal_models.APOD{}, tc.mockError).
 					Once()
-			} else {
-				apodGetterMock.On("GetAPOD", mock.Anything).
-					Return(&stellar_journal_models.APOD{}, nil).
+			case "GetRandomAPOD":
+				apodGetterMock.On("GetRandomAPOD", mock.Anything, source).
+					Return(&stellar_journal_models.APOD{}, tc.mockError).
 					Once()
 			}
 
-			handler := by_date.New(slogdiscard.NewDiscardLogger(), apodGetterMock)
+			handler := by_date.New(slogdiscard.NewDiscardLogger(), apodGetterMock, cachePolicy)
 
 			router := chi.NewRouter()
 			router.Get("/journal/{date}", handler)
 
-			url := fmt.Sprintf("/journal/%s", tc.date)
+			url := fmt.Sprintf("/journal/%s%s", tc.date, tc.query)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
@@ -86,7 +153,76 @@ func TestGetByDateHandler(t *testing.T) {
 
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 
-			require.Equal(t, tc.respError, resp.Error)
+			var respError string
+			if resp.Error != nil {
+				respError = resp.Error.Message
+			}
+			require.Equal(t, tc.respError, respError)
+			require.Equal(t, tc.cacheControl, rr.Header().Get("Cache-Control"))
 		})
 	}
 }
+
+func TestGetByDateHandlerConditional(t *testing.T) {
+	updatedAt := time.Date(2022, time.January, 1, 5, 0, 0, 0, time.UTC)
+
+	apodGetterMock := mocks.NewAPODByDateGetter(t)
+	apodGetterMock.On("GetAPOD", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2022-01-01")).
+		Return(&stellar_journal_models.APOD{Title: "Galaxy", UpdatedAt: updatedAt}, nil)
+
+	router := chi.NewRouter()
+	router.Get("/journal/{date}", by_date.New(slogdiscard.NewDiscardLogger(), apodGetterMock, cachePolicy))
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/journal/2022-01-01", nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := get("", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, "Sat, 01 Jan 2022 05:00:00 GMT", rr.Header().Get("Last-Modified"))
+
+	rr = get("If-None-Match", etag)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+	require.Equal(t, etag, rr.Header().Get("ETag"))
+	require.Equal(t, "public, max-age=3600", rr.Header().Get("Cache-Control"))
+
+	rr = get("If-None-Match", `"stale"`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = get("If-Modified-Since", "Sat, 01 Jan 2022 06:00:00 GMT")
+	require.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = get("If-Modified-Since", "Sat, 01 Jan 2022 04:00:00 GMT")
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestParseDate(t *testing.T) {
+	today := apod_date.MustParse("2024-03-01")
+
+	date, err := by_date.ParseDate(by_date.AliasToday, today)
+	require.NoError(t, err)
+	require.Equal(t, today, date)
+
+	date, err = by_date.ParseDate(by_date.AliasYesterday, today)
+	require.NoError(t, err)
+	require.Equal(t, apod_date.MustParse("2024-02-29"), date)
+
+	date, err = by_date.ParseDate("2024-02-01", today)
+	require.NoError(t, err)
+	require.Equal(t, apod_date.MustParse("2024-02-01"), date)
+
+	_, err = by_date.ParseDate("2024-03-02", today)
+	require.ErrorIs(t, err, apod_date.ErrInFuture)
+}
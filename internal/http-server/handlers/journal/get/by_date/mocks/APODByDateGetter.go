@@ -3,9 +3,13 @@
 package mocks
 
 import (
-	stellar_journal_models "stellar_journal/internal/models/stellar_journal_models"
+	apod_date "stellar_journal/internal/lib/apod_date"
 
 	mock "github.com/stretchr/testify/mock"
+
+	context "context"
+
+	stellar_journal_models "stellar_journal/internal/models/stellar_journal_models"
 )
 
 // APODByDateGetter is an autogenerated mock type for the APODByDateGetter type
@@ -13,25 +17,51 @@ type APODByDateGetter struct {
 	mock.Mock
 }
 
-// GetAPOD provides a mock function with given fields: date
-func (_m *APODByDateGetter) GetAPOD(date string) (*stellar_journal_models.APOD, error) {
-	ret := _m.Called(date)
+// GetAPOD provides a mock function with given fields: ctx, source, date
+func (_m *APODByDateGetter) GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	ret := _m.Called(ctx, source, date)
+
+	var r0 *stellar_journal_models.APOD
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, apod_date.Date) (*stellar_journal_models.APOD, error)); ok {
+		return rf(ctx, source, date)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, apod_date.Date) *stellar_journal_models.APOD); ok {
+		r0 = rf(ctx, source, date)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*stellar_journal_models.APOD)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, apod_date.Date) error); ok {
+		r1 = rf(ctx, source, date)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRandomAPOD provides a mock function with given fields: ctx, source
+func (_m *APODByDateGetter) GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error) {
+	ret := _m.Called(ctx, source)
 
 	var r0 *stellar_journal_models.APOD
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*stellar_journal_models.APOD, error)); ok {
-		return rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*stellar_journal_models.APOD, error)); ok {
+		return rf(ctx, source)
 	}
-	if rf, ok := ret.Get(0).(func(string) *stellar_journal_models.APOD); ok {
-		r0 = rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *stellar_journal_models.APOD); ok {
+		r0 = rf(ctx, source)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*stellar_journal_models.APOD)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(date)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, source)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/http-server/handlers/journal/get/by_date"
+	"stellar_journal/internal/lib/api/http_cache"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+	Data []stellar_journal_models.APODRevision `json:"data"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APODRevisionsGetter
+type APODRevisionsGetter interface {
+	GetAPODRevisions(ctx context.Context, source string, date apod_date.Date) ([]stellar_journal_models.APODRevision, error)
+}
+
+// New serves the prior versions of the entry of a date, the most recently
+// replaced first. Entries that were never corrected have an empty history. The
+// date and source are selected as in the by_date handler, without the random
+// alias. A history grows whenever the entry is corrected, so it is only cached
+// briefly; responses carry an ETag and Last-Modified, the time of the latest
+// correction, for conditional requests.
+func New(log *slog.Logger, revisionsGetter APODRevisionsGetter, cachePolicy http_cache.Policy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.journal.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		source, err := by_date.ParseSource(r.URL.Query().Get("source"))
+		if err != nil {
+			log.Info("invalid source", sl.Err(err))
+
+			resp.RenderError(w, r, resp.InvalidParameter("source", err.Error()))
+
+			return
+		}
+
+		date, err := by_date.ParseDate(chi.URLParam(r, "date"), apod_date.Today())
+		if err != nil {
+			log.Info("invalid date", sl.Err(err))
+
+			resp.RenderError(w, r, resp.InvalidParameter("date", err.Error()))
+
+			return
+		}
+
+		revisions, err := revisionsGetter.GetAPODRevisions(r.Context(), source, date)
+		if errors.Is(err, storage.ErrAPODNotFound) {
+			log.Info("apod not found", sl.Err(err))
+
+			resp.RenderError(w, r, resp.NotFound("apod not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get apod revisions", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to get apod history", err))
+
+			return
+		}
+
+		response := Response{
+			Response: resp.OK(),
+			Data:     revisions,
+		}
+
+		etag, err := http_cache.ETag(response)
+		if err != nil {
+			log.Error("failed to compute etag", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to get apod history", err))
+
+			return
+		}
+
+		w.Header().Set("Cache-Control", cachePolicy.Recent())
+		var lastModified time.Time
+		if len(revisions) > 0 {
+			lastModified = revisions[0].ReplacedAt
+		}
+		if http_cache.NotModified(w, r, etag, lastModified) {
+			return
+		}
+
+		render.JSON(w, r, response)
+	}
+}
@@ -0,0 +1,127 @@
+package history_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/api/http_cache"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/journal/get/history"
+	"stellar_journal/internal/http-server/handlers/journal/get/history/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	date := apod_date.MustParse("2022-01-01")
+	replacedAt := time.Date(2022, time.January, 1, 9, 0, 0, 0, time.UTC)
+	revisions := []stellar_journal_models.APODRevision{
+		{Id: 2, Title: "Galaxy", UpdatedAt: replacedAt.Add(-time.Hour), ReplacedAt: replacedAt},
+		{Id: 1, Title: "Galxy", UpdatedAt: replacedAt.Add(-5 * time.Hour), ReplacedAt: replacedAt.Add(-time.Hour)},
+	}
+
+	cases := []struct {
+		name         string
+		url          string
+		source       string
+		revisions    []stellar_journal_models.APODRevision
+		mockError    error
+		status       int
+		code         string
+		cacheControl string
+	}{
+		{name: "Revisions", url: "/journal/2022-01-01/history", revisions: revisions, status: http.StatusOK, cacheControl: "public, max-age=60"},
+		{name: "Never Corrected", url: "/journal/2022-01-01/history?source=bing", source: stellar_journal_models.SourceBing, revisions: []stellar_journal_models.APODRevision{}, status: http.StatusOK, cacheControl: "public, max-age=60"},
+		{name: "Not Found", url: "/journal/2022-01-01/history", mockError: fmt.Errorf("error: %w", storage.ErrAPODNotFound), status: http.StatusNotFound, code: resp.CodeNotFound},
+		{name: "Storage Fails", url: "/journal/2022-01-01/history", mockError: errors.New("connection refused"), status: http.StatusInternalServerError, code: resp.CodeInternal},
+		{name: "Invalid Date", url: "/journal/1990-01-01/history", status: http.StatusBadRequest, code: resp.CodeInvalidParameter},
+		{name: "Random Date", url: "/journal/random/history", status: http.StatusBadRequest, code: resp.CodeInvalidParameter},
+		{name: "Invalid Source", url: "/journal/2022-01-01/history?source=flickr", status: http.StatusBadRequest, code: resp.CodeInvalidParameter},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := tc.source
+			if source == "" {
+				source = stellar_journal_models.SourceNASAAPOD
+			}
+
+			getter := mocks.NewAPODRevisionsGetter(t)
+			if tc.revisions != nil || tc.mockError != nil {
+				getter.On("GetAPODRevisions", mock.Anything, source, date).Return(tc.revisions, tc.mockError).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Get("/journal/{date}/history", history.New(slogdiscard.NewDiscardLogger(), getter, http_cache.Policy{MaxAge: time.Hour, RecentMaxAge: time.Minute}))
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			require.Equal(t, tc.status, rr.Code)
+
+			if tc.code != "" {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				require.Equal(t, tc.code, body.Error.Code)
+				return
+			}
+
+			var body history.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.NotNil(t, body.Data, "history is a list even when empty")
+			require.Len(t, body.Data, len(tc.revisions))
+			require.Equal(t, tc.cacheControl, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestHistoryHandlerConditional(t *testing.T) {
+	replacedAt := time.Date(2022, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	getter := mocks.NewAPODRevisionsGetter(t)
+	getter.On("GetAPODRevisions", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2022-01-01")).
+		Return([]stellar_journal_models.APODRevision{{Id: 1, Title: "Galxy", ReplacedAt: replacedAt}}, nil)
+
+	router := chi.NewRouter()
+	router.Get("/journal/{date}/history", history.New(slogdiscard.NewDiscardLogger(), getter, http_cache.Policy{MaxAge: time.Hour, RecentMaxAge: time.Minute}))
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/journal/2022-01-01/history", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := get("", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, "Sat, 01 Jan 2022 09:00:00 GMT", rr.Header().Get("Last-Modified"))
+
+	rr = get("If-None-Match", etag)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+
+	rr = get("If-Modified-Since", "Sat, 01 Jan 2022 08:00:00 GMT")
+	require.Equal(t, http.StatusOK, rr.Code, "a correction since then changes the history")
+}
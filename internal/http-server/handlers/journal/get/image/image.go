@@ -0,0 +1,195 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"stellar_journal/internal/blob_store"
+	"stellar_journal/internal/http-server/handlers/journal/get/by_date"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/image_pipeline"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"strconv"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APODMediaGetter
+type APODMediaGetter interface {
+	GetAPODMedia(ctx context.Context, source string, date apod_date.Date, variant string) (*stellar_journal_models.APODMedia, error)
+	GetAPODDerivative(ctx context.Context, source string, date apod_date.Date, width int, format string) (*stellar_journal_models.APODDerivative, error)
+}
+
+// blobInfo is what the handler needs to know about an archived image or derivative.
+type blobInfo struct {
+	key         string
+	checksum    string
+	byteSize    int64
+	contentType string
+	modTime     time.Time
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=BlobGetter
+type BlobGetter interface {
+	Get(key string) (io.ReadCloser, error)
+}
+
+// New serves the archived image of an APOD. The variant query parameter selects
+// the hd (default) or sd image; width and format (jpeg by default) select a
+// resized derivative instead. The source query parameter selects the entry as
+// in the by_date handler.
+func New(log *slog.Logger, mediaGetter APODMediaGetter, blobs BlobGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.journal.image.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		date, err := by_date.ParseDate(chi.URLParam(r, "date"), apod_date.Today())
+		if err != nil {
+			log.Info("invalid date", sl.Err(err))
+
+			resp.RenderError(w, r, resp.InvalidParameter("date", err.Error()))
+
+			return
+		}
+
+		source, err := by_date.ParseSource(r.URL.Query().Get("source"))
+		if err != nil {
+			resp.RenderError(w, r, resp.InvalidParameter("source", err.Error()))
+
+			return
+		}
+
+		sel, err := parseSelector(r.URL.Query())
+		if err != nil {
+			resp.RenderError(w, r, err)
+
+			return
+		}
+
+		info, err := sel.lookup(r.Context(), mediaGetter, source, date)
+		if errors.Is(err, storage.ErrMediaNotFound) {
+			log.Info("image not archived", sl.Err(err))
+
+			resp.RenderError(w, r, resp.NotFound("image not archived"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get image", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to get image", err))
+
+			return
+		}
+
+		blob, err := blobs.Get(info.key)
+		if err != nil {
+			log.Error("failed to open image", slog.String("key", info.key), sl.Err(err))
+
+			if errors.Is(err, blob_store.ErrBlobNotFound) {
+				resp.RenderError(w, r, resp.NotFound("image not archived"))
+			} else {
+				resp.RenderError(w, r, resp.Internal("failed to get image", err))
+			}
+
+			return
+		}
+		defer func(blob io.ReadCloser) {
+			if err := blob.Close(); err != nil {
+				log.Error("failed to close image", sl.Err(err))
+			}
+		}(blob)
+
+		// Images are served from the journal's origin, so nothing may be
+		// rendered as a document: types archived before raster images were
+		// enforced are only offered for download.
+		contentType := info.contentType
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !image_pipeline.IsRasterType(mediaType) {
+			contentType = "application/octet-stream"
+			w.Header().Set("Content-Disposition", "attachment")
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'")
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, info.checksum))
+
+		if rs, ok := blob.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, "", info.modTime, rs)
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.FormatInt(info.byteSize, 10))
+		if _, err := io.Copy(w, blob); err != nil {
+			log.Error("failed to write image", sl.Err(err))
+		}
+	}
+}
+
+// selector identifies an archived image variant or a derivative (width > 0).
+type selector struct {
+	variant string
+	width   int
+	format  string
+}
+
+func parseSelector(query url.Values) (selector, error) {
+	if query.Get("width") != "" || query.Get("format") != "" {
+		width, err := strconv.Atoi(query.Get("width"))
+		if err != nil || width < 1 {
+			return selector{}, resp.InvalidParameter("width", "invalid width")
+		}
+
+		format := query.Get("format")
+		switch format {
+		case "":
+			format = image_pipeline.FormatJPEG
+		case image_pipeline.FormatJPEG, image_pipeline.FormatWebP:
+		default:
+			return selector{}, resp.InvalidParameter("format", "invalid format, expected jpeg or webp")
+		}
+
+		return selector{width: width, format: format}, nil
+	}
+
+	variant := query.Get("variant")
+	switch variant {
+	case "":
+		variant = stellar_journal_models.MediaVariantHD
+	case stellar_journal_models.MediaVariantHD, stellar_journal_models.MediaVariantSD:
+	default:
+		return selector{}, resp.InvalidParameter("variant", "invalid variant, expected hd or sd")
+	}
+
+	return selector{variant: variant}, nil
+}
+
+func (s selector) lookup(ctx context.Context, mediaGetter APODMediaGetter, source string, date apod_date.Date) (*blobInfo, error) {
+	if s.width > 0 {
+		d, err := mediaGetter.GetAPODDerivative(ctx, source, date, s.width, s.format)
+		if err != nil {
+			return nil, err
+		}
+
+		return &blobInfo{key: d.StorageKey, checksum: d.Checksum, byteSize: d.ByteSize, contentType: d.ContentType, modTime: d.CreatedAt}, nil
+	}
+
+	m, err := mediaGetter.GetAPODMedia(ctx, source, date, s.variant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blobInfo{key: m.StorageKey, checksum: m.Checksum, byteSize: m.ByteSize, contentType: m.ContentType, modTime: m.CreatedAt}, nil
+}
@@ -0,0 +1,226 @@
+package image_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/blob_store"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/journal/get/image"
+	"stellar_journal/internal/http-server/handlers/journal/get/image/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+// readSeekCloser mimics the *os.File returned by the filesystem blob store.
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekCloser) Close() error { return nil }
+
+func TestImageHandler(t *testing.T) {
+	content := []byte("\xff\xd8\xff\xe0 fake jpeg")
+	media := &stellar_journal_models.APODMedia{
+		Date:        apod_date.MustParse("2022-01-01"),
+		Variant:     stellar_journal_models.MediaVariantHD,
+		StorageKey:  "2022-01-01/hd.jpg",
+		Checksum:    "abc",
+		ByteSize:    int64(len(content)),
+		ContentType: "image/jpeg",
+	}
+
+	cases := []struct {
+		name       string
+		url        string
+		source     string
+		variant    string
+		derivative bool
+		media      *stellar_journal_models.APODMedia
+		mediaErr   error
+		blob       io.ReadCloser
+		blobErr    error
+		status     int
+		body       []byte
+		getsBlob   bool
+		getsMedia  bool
+	}{
+		{
+			name:      "Success",
+			url:       "/journal/2022-01-01/image",
+			variant:   stellar_journal_models.MediaVariantHD,
+			media:     media,
+			blob:      readSeekCloser{bytes.NewReader(content)},
+			status:    http.StatusOK,
+			body:      content,
+			getsMedia: true,
+			getsBlob:  true,
+		},
+		{
+			name:      "Non Seekable Blob",
+			url:       "/journal/2022-01-01/image?variant=sd",
+			variant:   stellar_journal_models.MediaVariantSD,
+			media:     media,
+			blob:      io.NopCloser(bytes.NewReader(content)),
+			status:    http.StatusOK,
+			body:      content,
+			getsMedia: true,
+			getsBlob:  true,
+		},
+		{
+			name:       "Derivative",
+			url:        "/journal/2022-01-01/image?width=320&format=webp",
+			derivative: true,
+			media:      media,
+			blob:       readSeekCloser{bytes.NewReader(content)},
+			status:     http.StatusOK,
+			body:       content,
+			getsMedia:  true,
+			getsBlob:   true,
+		},
+		{
+			name:      "Other Source",
+			url:       "/journal/2022-01-01/image?source=esa_hubble",
+			source:    stellar_journal_models.SourceESAHubble,
+			variant:   stellar_journal_models.MediaVariantHD,
+			media:     media,
+			blob:      readSeekCloser{bytes.NewReader(content)},
+			status:    http.StatusOK,
+			body:      content,
+			getsMedia: true,
+			getsBlob:  true,
+		},
+		{
+			name:   "Unknown Source",
+			url:    "/journal/2022-01-01/image?source=flickr",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid Width",
+			url:    "/journal/2022-01-01/image?format=webp",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid Variant",
+			url:    "/journal/2022-01-01/image?variant=xl",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid Date",
+			url:    "/journal/2022-13-01/image",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:      "Not Archived",
+			url:       "/journal/2022-01-01/image",
+			variant:   stellar_journal_models.MediaVariantHD,
+			mediaErr:  storage.ErrMediaNotFound,
+			status:    http.StatusNotFound,
+			getsMedia: true,
+		},
+		{
+			name:      "GetAPODMedia Error",
+			url:       "/journal/2022-01-01/image",
+			variant:   stellar_journal_models.MediaVariantHD,
+			mediaErr:  errors.New("db is down"),
+			status:    http.StatusInternalServerError,
+			getsMedia: true,
+		},
+		{
+			name:      "Blob Missing",
+			url:       "/journal/2022-01-01/image",
+			variant:   stellar_journal_models.MediaVariantHD,
+			media:     media,
+			blobErr:   blob_store.ErrBlobNotFound,
+			status:    http.StatusNotFound,
+			getsMedia: true,
+			getsBlob:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mediaGetterMock := mocks.NewAPODMediaGetter(t)
+			blobGetterMock := mocks.NewBlobGetter(t)
+
+			source := tc.source
+			if source == "" {
+				source = stellar_journal_models.SourceNASAAPOD
+			}
+
+			if tc.getsMedia && tc.derivative {
+				mediaGetterMock.On("GetAPODDerivative", mock.Anything, source, apod_date.MustParse("2022-01-01"), 320, "webp").
+					Return(&stellar_journal_models.APODDerivative{
+						StorageKey:  tc.media.StorageKey,
+						Checksum:    tc.media.Checksum,
+						ByteSize:    tc.media.ByteSize,
+						ContentType: tc.media.ContentType,
+					}, tc.mediaErr).
+					Once()
+			} else if tc.getsMedia {
+				mediaGetterMock.On("GetAPODMedia", mock.Anything, source, apod_date.MustParse("2022-01-01"), tc.variant).
+					Return(tc.media, tc.mediaErr).
+					Once()
+			}
+			if tc.getsBlob {
+				blobGetterMock.On("Get", media.StorageKey).
+					Return(tc.blob, tc.blobErr).
+					Once()
+			}
+
+			router := chi.NewRouter()
+			router.Get("/journal/{date}/image", image.New(slogdiscard.NewDiscardLogger(), mediaGetterMock, blobGetterMock))
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			if tc.body != nil {
+				require.Equal(t, tc.body, rr.Body.Bytes())
+				require.Equal(t, "image/jpeg", rr.Header().Get("Content-Type"))
+				require.Equal(t, `"abc"`, rr.Header().Get("ETag"))
+				require.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+				require.Equal(t, "default-src 'none'", rr.Header().Get("Content-Security-Policy"))
+			}
+		})
+	}
+}
+
+func TestImageHandlerDownloadsOtherTypes(t *testing.T) {
+	content := []byte("<script>alert(1)</script>")
+
+	mediaGetterMock := mocks.NewAPODMediaGetter(t)
+	mediaGetterMock.On("GetAPODMedia", mock.Anything, stellar_journal_models.SourceNASAAPOD, apod_date.MustParse("2022-01-01"), stellar_journal_models.MediaVariantHD).
+		Return(&stellar_journal_models.APODMedia{StorageKey: "2022-01-01/hd.html", Checksum: "abc", ByteSize: int64(len(content)), ContentType: "text/html; charset=utf-8"}, nil).
+		Once()
+	blobGetterMock := mocks.NewBlobGetter(t)
+	blobGetterMock.On("Get", "2022-01-01/hd.html").Return(readSeekCloser{bytes.NewReader(content)}, nil).Once()
+
+	router := chi.NewRouter()
+	router.Get("/journal/{date}/image", image.New(slogdiscard.NewDiscardLogger(), mediaGetterMock, blobGetterMock))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/journal/2022-01-01/image", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+	require.Equal(t, "attachment", rr.Header().Get("Content-Disposition"))
+	require.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type Response struct {
+	resp.Response
+	Data []stellar_journal_models.SearchResult `json:"data"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=JournalSearcher
+type JournalSearcher interface {
+	SearchJournal(ctx context.Context, query string, limit int) ([]stellar_journal_models.SearchResult, error)
+}
+
+func New(log *slog.Logger, journalSearcher JournalSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.journal.search.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			resp.RenderError(w, r, resp.InvalidParameter("q", "missing search query"))
+
+			return
+		}
+
+		limit := DefaultLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n < 1 {
+				resp.RenderError(w, r, resp.InvalidParameter("limit", "invalid limit"))
+
+				return
+			}
+			limit = min(n, MaxLimit)
+		}
+
+		results, err := journalSearcher.SearchJournal(r.Context(), query, limit)
+		if err != nil {
+			log.Error("failed to search journal", sl.Err(err))
+
+			resp.RenderError(w, r, resp.Internal("failed to search journal", err))
+
+			return
+		}
+
+		responseOK(w, r, results)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, data []stellar_journal_models.SearchResult) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+		Data:     data,
+	})
+}
@@ -0,0 +1,111 @@
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/handlers/journal/get/search"
+	"stellar_journal/internal/http-server/handlers/journal/get/search/mocks"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestSearchHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		url       string
+		query     string
+		limit     int
+		results   []stellar_journal_models.SearchResult
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:  "Success",
+			url:   "/journal/search?q=horsehead+nebula",
+			query: "horsehead nebula",
+			limit: search.DefaultLimit,
+			results: []stellar_journal_models.SearchResult{
+				{
+					APOD:           stellar_journal_models.APOD{Title: "The Horsehead Nebula"},
+					Rank:           0.9,
+					TitleHighlight: "The <mark>Horsehead</mark> <mark>Nebula</mark>",
+				},
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "Custom Limit",
+			url:     "/journal/search?q=moon&limit=5",
+			query:   "moon",
+			limit:   5,
+			results: []stellar_journal_models.SearchResult{},
+			status:  http.StatusOK,
+		},
+		{
+			name:      "Missing Query",
+			url:       "/journal/search?q=+",
+			respError: "missing search query",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid Limit",
+			url:       "/journal/search?q=moon&limit=-1",
+			respError: "invalid limit",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "SearchJournal Error",
+			url:       "/journal/search?q=moon",
+			query:     "moon",
+			limit:     search.DefaultLimit,
+			respError: "failed to search journal",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("failed to search journal"),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			searcherMock := mocks.NewJournalSearcher(t)
+
+			if tc.query != "" {
+				searcherMock.On("SearchJournal", mock.Anything, tc.query, tc.limit).
+					Return(tc.results, tc.mockError).
+					Once()
+			}
+
+			handler := search.New(slogdiscard.NewDiscardLogger(), searcherMock)
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp search.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			var respError string
+			if resp.Error != nil {
+				respError = resp.Error.Message
+			}
+			require.Equal(t, tc.respError, respError)
+			require.Len(t, resp.Data, len(tc.results))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/api_key"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=KeyStore
+type KeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, hash []byte) (*stellar_journal_models.APIKey, error)
+	RecordAPIKeyUsage(ctx context.Context, id int, now time.Time) (int64, error)
+}
+
+type ctxKey struct{}
+
+// KeyFromContext returns the API key a request was authenticated with.
+func KeyFromContext(ctx context.Context) (*stellar_journal_models.APIKey, bool) {
+	key, ok := ctx.Value(ctxKey{}).(*stellar_journal_models.APIKey)
+	return key, ok
+}
+
+// New rejects requests without a valid API key, sent in X-API-Key or as a
+// bearer token, and requests of keys that used up their daily quota. Usage is
+// counted per UTC day; keys with a quota get X-Quota-* headers.
+func New(log *slog.Logger, keys KeyStore, clk clock.Clock) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			raw := keyFromRequest(r)
+			if raw == "" {
+				unauthorized(w, r, "missing API key")
+				return
+			}
+
+			key, err := keys.GetAPIKeyByHash(r.Context(), api_key.Hash(raw))
+			if errors.Is(err, storage.ErrAPIKeyNotFound) {
+				unauthorized(w, r, "invalid API key")
+				return
+			}
+			if err != nil {
+				log.Error("failed to get API key", slog.String("request_id", middleware.GetReqID(r.Context())), sl.Err(err))
+				response.RenderError(w, r, response.Internal("internal error", err))
+				return
+			}
+			if key.RevokedAt != nil {
+				unauthorized(w, r, "API key revoked")
+				return
+			}
+
+			now := clk.Now()
+			requests, err := keys.RecordAPIKeyUsage(r.Context(), key.ID, now)
+			if err != nil {
+				log.Error("failed to record API key usage", slog.String("request_id", middleware.GetReqID(r.Context())), sl.Err(err))
+				response.RenderError(w, r, response.Internal("internal error", err))
+				return
+			}
+			key.RequestsToday = requests
+
+			if key.DailyQuota > 0 {
+				reset := nextUTCDay(now)
+				w.Header().Set("X-Quota-Limit", strconv.Itoa(key.DailyQuota))
+				w.Header().Set("X-Quota-Remaining", strconv.FormatInt(max(int64(key.DailyQuota)-requests, 0), 10))
+				w.Header().Set("X-Quota-Reset", strconv.Itoa(int(reset.Sub(now).Seconds())))
+
+				if requests > int64(key.DailyQuota) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())))
+					response.RenderError(w, r, response.QuotaExceeded("daily quota exceeded"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, key)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireAdmin rejects requests that New did not authenticate with an admin
+// key.
+func RequireAdmin() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key, ok := KeyFromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "missing API key")
+				return
+			}
+			if !key.Admin {
+				response.RenderError(w, r, response.Forbidden("admin API key required"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func keyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="stellar_journal"`)
+	response.RenderError(w, r, response.Unauthorized(msg))
+}
+
+func nextUTCDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,221 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/api_key"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/middleware/auth"
+	"stellar_journal/internal/http-server/middleware/auth/mocks"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	const key = "sj_valid"
+	now := time.Date(2024, time.January, 2, 18, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Hour)
+
+	cases := []struct {
+		name           string
+		header         string
+		value          string
+		apiKey         *stellar_journal_models.APIKey
+		lookupError    error
+		requests       int64
+		status         int
+		code           string
+		quotaLimit     string
+		quotaRemaining string
+	}{
+		{
+			name:   "X-API-Key",
+			header: auth.HeaderAPIKey,
+			value:  key,
+			apiKey: &stellar_journal_models.APIKey{ID: 1},
+			status: http.StatusOK,
+		},
+		{
+			name:   "Bearer Token",
+			header: "Authorization",
+			value:  "Bearer " + key,
+			apiKey: &stellar_journal_models.APIKey{ID: 1},
+			status: http.StatusOK,
+		},
+		{
+			name:   "Missing Key",
+			status: http.StatusUnauthorized,
+			code:   response.CodeUnauthorized,
+		},
+		{
+			name:   "Basic Auth",
+			header: "Authorization",
+			value:  "Basic dXNlcjpwYXNz",
+			status: http.StatusUnauthorized,
+			code:   response.CodeUnauthorized,
+		},
+		{
+			name:        "Unknown Key",
+			header:      auth.HeaderAPIKey,
+			value:       key,
+			lookupError: storage.ErrAPIKeyNotFound,
+			status:      http.StatusUnauthorized,
+			code:        response.CodeUnauthorized,
+		},
+		{
+			name:   "Revoked Key",
+			header: auth.HeaderAPIKey,
+			value:  key,
+			apiKey: &stellar_journal_models.APIKey{ID: 1, RevokedAt: &revokedAt},
+			status: http.StatusUnauthorized,
+			code:   response.CodeUnauthorized,
+		},
+		{
+			name:        "Lookup Error",
+			header:      auth.HeaderAPIKey,
+			value:       key,
+			lookupError: errors.New("db is down"),
+			status:      http.StatusInternalServerError,
+			code:        response.CodeInternal,
+		},
+		{
+			name:           "Within Quota",
+			header:         auth.HeaderAPIKey,
+			value:          key,
+			apiKey:         &stellar_journal_models.APIKey{ID: 1, DailyQuota: 100},
+			requests:       100,
+			status:         http.StatusOK,
+			quotaLimit:     "100",
+			quotaRemaining: "0",
+		},
+		{
+			name:           "Quota Exceeded",
+			header:         auth.HeaderAPIKey,
+			value:          key,
+			apiKey:         &stellar_journal_models.APIKey{ID: 1, DailyQuota: 100},
+			requests:       101,
+			status:         http.StatusTooManyRequests,
+			code:           response.CodeQuotaExceeded,
+			quotaLimit:     "100",
+			quotaRemaining: "0",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			keysMock := mocks.NewKeyStore(t)
+			if tc.apiKey != nil || tc.lookupError != nil {
+				keysMock.On("GetAPIKeyByHash", mock.Anything, api_key.Hash(key)).Return(tc.apiKey, tc.lookupError).Once()
+			}
+			if tc.apiKey != nil && tc.apiKey.RevokedAt == nil {
+				keysMock.On("RecordAPIKeyUsage", mock.Anything, tc.apiKey.ID, now).Return(tc.requests, nil).Once()
+			}
+
+			var authenticated *stellar_journal_models.APIKey
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authenticated, _ = auth.KeyFromContext(r.Context())
+			})
+			handler := auth.New(slogdiscard.NewDiscardLogger(), keysMock, fakeclock.New(now))(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/journal", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, tc.quotaLimit, rr.Header().Get("X-Quota-Limit"))
+			require.Equal(t, tc.quotaRemaining, rr.Header().Get("X-Quota-Remaining"))
+
+			if tc.status != http.StatusOK {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.code, resp.Error.Code)
+				require.Nil(t, authenticated)
+				return
+			}
+
+			require.Equal(t, tc.apiKey.ID, authenticated.ID)
+			require.Equal(t, tc.requests, authenticated.RequestsToday)
+		})
+	}
+}
+
+func TestQuotaResetsAtUTCMidnight(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 18, 0, 0, 0, time.UTC)
+	keysMock := mocks.NewKeyStore(t)
+	keysMock.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(&stellar_journal_models.APIKey{ID: 1, DailyQuota: 1}, nil).Once()
+	keysMock.On("RecordAPIKeyUsage", mock.Anything, 1, now).Return(int64(2), nil).Once()
+
+	handler := auth.New(slogdiscard.NewDiscardLogger(), keysMock, fakeclock.New(now))(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/journal", nil)
+	req.Header.Set(auth.HeaderAPIKey, "sj_key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "21600", rr.Header().Get("X-Quota-Reset"))
+	require.Equal(t, "21600", rr.Header().Get("Retry-After"))
+}
+
+func TestRequireAdmin(t *testing.T) {
+	cases := []struct {
+		name   string
+		admin  bool
+		status int
+		code   string
+	}{
+		{name: "Admin Key", admin: true, status: http.StatusOK},
+		{name: "Other Key", status: http.StatusForbidden, code: response.CodeForbidden},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			keysMock := mocks.NewKeyStore(t)
+			keysMock.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(&stellar_journal_models.APIKey{ID: 1, Admin: tc.admin}, nil).Once()
+			keysMock.On("RecordAPIKeyUsage", mock.Anything, 1, mock.Anything).Return(int64(1), nil).Once()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			handler := auth.New(slogdiscard.NewDiscardLogger(), keysMock, fakeclock.New(time.Now()))(auth.RequireAdmin()(next))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/fetch", nil)
+			req.Header.Set(auth.HeaderAPIKey, "sj_key")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.code != "" {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.code, resp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAdminWithoutAuthentication(t *testing.T) {
+	rr := httptest.NewRecorder()
+	auth.RequireAdmin()(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/fetch", nil))
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/middleware/metrics"
+)
+
+type observation struct {
+	method, route string
+	status        int
+}
+
+type recorder struct {
+	observations []observation
+}
+
+func (r *recorder) ObserveRequest(method, route string, status int, _ time.Duration) {
+	r.observations = append(r.observations, observation{method, route, status})
+}
+
+func TestMiddlewareRecordsRoutePattern(t *testing.T) {
+	rec := &recorder{}
+	router := chi.NewRouter()
+	router.Use(metrics.New(rec))
+	router.Route("/journal", func(r chi.Router) {
+		r.Get("/{date}", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})
+		r.Get("/{date}/image", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+	})
+
+	for _, path := range []string{"/journal/2024-01-02", "/journal/2024-01-03", "/journal/2024-01-02/image", "/wp-login.php"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, []observation{
+		{http.MethodGet, "/journal/{date}", http.StatusOK},
+		{http.MethodGet, "/journal/{date}", http.StatusOK},
+		{http.MethodGet, "/journal/{date}/image", http.StatusNotFound},
+		{http.MethodGet, "unmatched", http.StatusNotFound},
+	}, rec.observations)
+}
@@ -0,0 +1,161 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"stellar_journal/internal/http-server/middleware/auth"
+	"stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/logger/sl"
+	rl "stellar_journal/internal/rate_limit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type Limiter interface {
+	Allow(ctx context.Context, key string) (rl.Decision, error)
+}
+
+// KeyFunc returns the key of the bucket a request takes a token from, or an
+// empty key for requests this limiter does not count.
+type KeyFunc func(r *http.Request) string
+
+// New takes a token from the client's bucket for every request and answers
+// 429 when there is none. Responses carry the RateLimit-* headers of the IETF
+// draft. Requests are let through when the limiter fails, so that an
+// unreachable shared backend does not take the API down.
+func New(log *slog.Logger, limiter Limiter, policy rl.Policy, key KeyFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/rate_limit"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			d, err := limiter.Allow(r.Context(), k)
+			if err != nil {
+				log.Warn("rate limiter failed, letting the request through",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					sl.Err(err),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", policy.String())
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(d.Reset))
+
+			if !d.Allowed {
+				w.Header().Set("Retry-After", seconds(d.RetryAfter))
+				response.RenderError(w, r, response.RateLimited("too many requests"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// seconds rounds up, so that clients waiting as told find a token.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// ByIP keys requests by the client address. Behind the trusted proxies the
+// address is taken from X-Forwarded-For: the rightmost one not of a trusted
+// proxy, since clients can put anything before it.
+func ByIP(trusted []netip.Prefix) KeyFunc {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		client, err := netip.ParseAddr(host)
+		if err != nil {
+			return "ip:" + host
+		}
+		client = client.Unmap()
+
+		if isTrusted(client) {
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = hop.Unmap()
+				if !isTrusted(client) {
+					break
+				}
+			}
+		}
+
+		return "ip:" + client.String()
+	}
+}
+
+// ByAPIKey keys requests by the API key the auth middleware accepted, and
+// requests without one by fallback. A nil fallback leaves them uncounted, for
+// when they are limited by IP already.
+func ByAPIKey(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if key, ok := auth.KeyFromContext(r.Context()); ok {
+			return "key:" + strconv.Itoa(key.ID)
+		}
+		if fallback == nil {
+			return ""
+		}
+
+		return fallback(r)
+	}
+}
+
+// ParseTrustedProxies parses addresses and CIDR prefixes.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	const op = "internal/http-server/middleware/rate_limit.ParseTrustedProxies"
+
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,184 @@
+package rate_limit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/api/response"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/models/stellar_journal_models"
+	rl "stellar_journal/internal/rate_limit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"stellar_journal/internal/http-server/middleware/auth"
+	authmocks "stellar_journal/internal/http-server/middleware/auth/mocks"
+	"stellar_journal/internal/http-server/middleware/rate_limit"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+)
+
+type stubLimiter struct {
+	decision rl.Decision
+	err      error
+	keys     []string
+}
+
+func (l *stubLimiter) Allow(_ context.Context, key string) (rl.Decision, error) {
+	l.keys = append(l.keys, key)
+	return l.decision, l.err
+}
+
+var policy = rl.Policy{Limit: 60, Window: time.Minute}
+
+func serve(limiter rate_limit.Limiter, key rate_limit.KeyFunc, req *http.Request) *httptest.ResponseRecorder {
+	handler := rate_limit.New(slogdiscard.NewDiscardLogger(), limiter, policy, key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestMiddleware(t *testing.T) {
+	cases := []struct {
+		name      string
+		limiter   *stubLimiter
+		status    int
+		headers   map[string]string
+		errorCode string
+	}{
+		{
+			name:    "Allowed",
+			limiter: &stubLimiter{decision: policy.Decision(true, 58.5)},
+			status:  http.StatusOK,
+			headers: map[string]string{
+				"RateLimit-Policy":    "60;w=60",
+				"RateLimit-Limit":     "60",
+				"RateLimit-Remaining": "58",
+				"RateLimit-Reset":     "2",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:    "Denied",
+			limiter: &stubLimiter{decision: policy.Decision(false, 0.25)},
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "1",
+			},
+			errorCode: response.CodeRateLimited,
+		},
+		{
+			name:    "Limiter Fails Open",
+			limiter: &stubLimiter{err: errors.New("connection refused")},
+			status:  http.StatusOK,
+			headers: map[string]string{"RateLimit-Limit": ""},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := serve(tc.limiter, rate_limit.ByIP(nil), httptest.NewRequest(http.MethodGet, "/journal", nil))
+
+			require.Equal(t, tc.status, rr.Code)
+			for name, value := range tc.headers {
+				require.Equal(t, value, rr.Header().Get(name), name)
+			}
+			require.Equal(t, []string{"ip:192.0.2.1"}, tc.limiter.keys)
+
+			if tc.errorCode != "" {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.errorCode, resp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestByIP(t *testing.T) {
+	trusted, err := rate_limit.ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  []string
+		key           string
+		trustedHeader bool
+	}{
+		{name: "Direct", remoteAddr: "198.51.100.7:4321", key: "ip:198.51.100.7"},
+		{name: "Untrusted Proxy Ignored", remoteAddr: "198.51.100.7:4321", forwardedFor: []string{"203.0.113.9"}, key: "ip:198.51.100.7"},
+		{name: "Trusted Proxy", remoteAddr: "10.0.0.2:4321", forwardedFor: []string{"203.0.113.9"}, key: "ip:203.0.113.9"},
+		{name: "Spoofed Hop", remoteAddr: "10.0.0.2:4321", forwardedFor: []string{"1.2.3.4, 203.0.113.9"}, key: "ip:203.0.113.9"},
+		{name: "Proxy Chain", remoteAddr: "10.0.0.2:4321", forwardedFor: []string{"203.0.113.9, 10.0.0.3", "10.0.0.4"}, key: "ip:203.0.113.9"},
+		{name: "Only Proxies", remoteAddr: "10.0.0.2:4321", forwardedFor: []string{"10.0.0.3"}, key: "ip:10.0.0.3"},
+		{name: "Invalid Hop", remoteAddr: "10.0.0.2:4321", forwardedFor: []string{"203.0.113.9, unknown"}, key: "ip:10.0.0.2"},
+		{name: "IPv6 Proxy", remoteAddr: "[2001:db8::1]:4321", forwardedFor: []string{"2001:db8::42"}, key: "ip:2001:db8::42"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/journal", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			require.Equal(t, tc.key, rate_limit.ByIP(trusted)(req))
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalid(t *testing.T) {
+	_, err := rate_limit.ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = rate_limit.ParseTrustedProxies([]string{"proxy.local"})
+	require.Error(t, err)
+}
+
+func TestByAPIKey(t *testing.T) {
+	keys := authmocks.NewKeyStore(t)
+	keys.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(&stellar_journal_models.APIKey{ID: 7}, nil).Once()
+	keys.On("RecordAPIKeyUsage", mock.Anything, 7, mock.Anything).Return(int64(1), nil).Once()
+
+	var got []string
+	key := rate_limit.ByAPIKey(rate_limit.ByIP(nil))
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, key(r))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/journal", nil)
+	req.Header.Set(auth.HeaderAPIKey, "sj_key")
+	auth.New(slogdiscard.NewDiscardLogger(), keys, clock.System)(record).ServeHTTP(httptest.NewRecorder(), req)
+	record.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/journal", nil))
+
+	require.Equal(t, []string{"key:7", "ip:192.0.2.1"}, got)
+}
+
+func TestMiddlewareSkipsEmptyKey(t *testing.T) {
+	limiter := &stubLimiter{decision: policy.Decision(false, 0)}
+
+	rr := serve(limiter, rate_limit.ByAPIKey(nil), httptest.NewRequest(http.MethodGet, "/journal", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	require.Empty(t, limiter.keys, "requests without a key are not counted")
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"stellar_journal/internal/http-server/handlers/admin/backfill"
+	adminJournal "stellar_journal/internal/http-server/handlers/admin/journal"
+	adminWorkers "stellar_journal/internal/http-server/handlers/admin/workers"
+	healthHandler "stellar_journal/internal/http-server/handlers/health"
+	"stellar_journal/internal/http-server/handlers/journal/get/all"
+	"stellar_journal/internal/http-server/handlers/journal/get/by_date"
+	"stellar_journal/internal/http-server/handlers/journal/get/history"
+	"stellar_journal/internal/http-server/handlers/journal/get/image"
+	"stellar_journal/internal/http-server/handlers/journal/get/search"
+	mwAuth "stellar_journal/internal/http-server/middleware/auth"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/clock"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Journal holds what the /journal routes read from. The image route is only
+// served when Blobs is set, that is when the media archive is enabled.
+type Journal struct {
+	Pages       all.JournalGetter
+	Entries     by_date.APODByDateGetter
+	Searcher    search.JournalSearcher
+	Revisions   history.APODRevisionsGetter
+	Media       image.APODMediaGetter
+	Blobs       image.BlobGetter
+	CachePolicy http_cache.Policy
+}
+
+// Admin holds what the /admin routes act on. Cache may be nil.
+type Admin struct {
+	Fetcher      adminJournal.Fetcher
+	FetchTimeout time.Duration
+	Deleter      adminJournal.Deleter
+	Cache        adminJournal.CacheInvalidator
+	Backfills    backfill.Runner
+	Workers      adminWorkers.StatsGetter
+}
+
+// Options configures the routes of the API. The journal requires an API key
+// when Auth is set; the admin routes, served unless Admin is nil, always do.
+// LimitIP, applied before authentication, and LimitKey, applied after it, may
+// be nil.
+type Options struct {
+	Log      *slog.Logger
+	Checker  healthHandler.Checker
+	Keys     mwAuth.KeyStore
+	Clock    clock.Clock
+	Auth     bool
+	LimitIP  func(http.Handler) http.Handler
+	LimitKey func(http.Handler) http.Handler
+	Journal  Journal
+	Admin    *Admin
+}
+
+// Mount adds the routes described by the OpenAPI document to r, so that the
+// server and the tests of the document serve the same routes.
+func Mount(r chi.Router, opts Options) {
+	log := opts.Log
+
+	r.Get("/healthz", healthHandler.Live())
+	r.Get("/readyz", healthHandler.Ready(log, opts.Checker))
+
+	j := opts.Journal
+	r.Route("/journal", func(r chi.Router) {
+		// Limiting by IP comes before authentication, so that floods, bogus
+		// keys included, never reach the key lookups; limiting by key needs
+		// the key.
+		if opts.LimitIP != nil {
+			r.Use(opts.LimitIP)
+		}
+		if opts.Auth {
+			r.Use(mwAuth.New(log, opts.Keys, opts.Clock))
+		}
+		if opts.LimitKey != nil {
+			r.Use(opts.LimitKey)
+		}
+		r.Get("/", all.New(log, j.Pages, j.CachePolicy))
+		r.Get("/search", search.New(log, j.Searcher))
+		r.Get("/{date}", by_date.New(log, j.Entries, j.CachePolicy))
+		r.Get("/{date}/history", history.New(log, j.Revisions, j.CachePolicy))
+		if j.Blobs != nil {
+			r.Get("/{date}/image", image.New(log, j.Media, j.Blobs))
+		}
+	})
+
+	// Admin routes are authenticated whether or not the journal is.
+	if a := opts.Admin; a != nil {
+		r.Route("/admin", func(r chi.Router) {
+			if opts.LimitIP != nil {
+				r.Use(opts.LimitIP)
+			}
+			r.Use(mwAuth.New(log, opts.Keys, opts.Clock))
+			r.Use(mwAuth.RequireAdmin())
+			r.Get("/workers", adminWorkers.New(a.Workers))
+			r.Post("/journal/{date}/fetch", adminJournal.Fetch(log, a.Fetcher, a.FetchTimeout))
+			r.Delete("/journal/{date}", adminJournal.Delete(log, a.Deleter, a.Cache))
+			r.Post("/backfill", backfill.Start(log, a.Backfills))
+			r.Get("/backfill", backfill.Status(a.Backfills))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package http_cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"stellar_journal/internal/lib/apod_date"
+	"strings"
+	"time"
+)
+
+// NoStore is the Cache-Control of responses that differ on every request, such
+// as a random entry.
+const NoStore = "no-store"
+
+// Policy tells caches how long they may serve a response. Today's entry may
+// still be published, lists grow every day and the entries of the last
+// RecentDays days before today may still be corrected, so they get
+// RecentMaxAge. Older entries rarely change and get MaxAge; a correction of
+// one reaches clients once it expired. Private responses, such as those that
+// require an API key, may only be kept by the client's own cache, so that
+// shared caches never serve them to clients without a key.
+type Policy struct {
+	MaxAge       time.Duration
+	RecentMaxAge time.Duration
+	RecentDays   int
+	Private      bool
+}
+
+// ForDate returns the Cache-Control of the entry of date.
+func (p Policy) ForDate(date, today apod_date.Date) string {
+	if date.Before(today.AddDays(-p.RecentDays)) {
+		return p.cacheControl(p.MaxAge)
+	}
+
+	return p.Recent()
+}
+
+// Recent returns the Cache-Control of responses that change daily or may be
+// corrected any time: recent entries, the today and yesterday aliases, journal
+// pages and entry histories.
+func (p Policy) Recent() string {
+	return p.cacheControl(p.RecentMaxAge)
+}
+
+func (p Policy) cacheControl(maxAge time.Duration) string {
+	visibility := "public"
+	if p.Private {
+		visibility = "private"
+	}
+
+	return fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds()))
+}
+
+// ETag returns a strong entity tag derived from the JSON encoding of v.
+func ETag(v any) (string, error) {
+	const op = "internal/lib/api/http_cache.ETag"
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to encode value: %w", op, err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// NotModified sets the ETag and, unless zero, the Last-Modified header and
+// reports whether the conditional headers of r match them. In that case it
+// writes 304 Not Modified and the caller must not write a body. If-None-Match
+// takes precedence over If-Modified-Since.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	var match bool
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		match = etagMatches(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		match = err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	if !match {
+		return false
+	}
+
+	// A 304 must not carry representation headers set for the body.
+	w.Header().Del("Content-Type")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// etagMatches performs the weak comparison If-None-Match requires.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package http_cache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/api/http_cache"
+	"stellar_journal/internal/lib/apod_date"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := http_cache.Policy{MaxAge: 7 * 24 * time.Hour, RecentMaxAge: 5 * time.Minute}
+	today := apod_date.MustParse("2024-01-10")
+
+	require.Equal(t, "public, max-age=604800", policy.ForDate(today.AddDays(-1), today))
+	require.Equal(t, "public, max-age=300", policy.ForDate(today, today))
+	require.Equal(t, "public, max-age=300", policy.Recent())
+
+	policy.RecentDays = 3
+	require.Equal(t, "public, max-age=300", policy.ForDate(today.AddDays(-3), today), "may still be corrected")
+	require.Equal(t, "public, max-age=604800", policy.ForDate(today.AddDays(-4), today))
+
+	policy.RecentDays = 0
+	policy.Private = true
+	require.Equal(t, "private, max-age=604800", policy.ForDate(today.AddDays(-1), today))
+	require.Equal(t, "private, max-age=300", policy.Recent())
+}
+
+func TestETag(t *testing.T) {
+	first, err := http_cache.ETag(map[string]string{"title": "Galaxy"})
+	require.NoError(t, err)
+	require.Regexp(t, `^"[0-9a-f]{32}"$`, first)
+
+	same, err := http_cache.ETag(map[string]string{"title": "Galaxy"})
+	require.NoError(t, err)
+	require.Equal(t, first, same)
+
+	other, err := http_cache.ETag(map[string]string{"title": "Nebula"})
+	require.NoError(t, err)
+	require.NotEqual(t, first, other)
+}
+
+func TestNotModified(t *testing.T) {
+	const etag = `"abc"`
+	lastModified := time.Date(2024, time.January, 10, 5, 0, 0, 500, time.UTC)
+
+	cases := []struct {
+		name        string
+		method      string
+		header      string
+		value       string
+		notModified bool
+	}{
+		{name: "Unconditional"},
+		{name: "Matching ETag", header: "If-None-Match", value: `"abc"`, notModified: true},
+		{name: "Weak ETag", header: "If-None-Match", value: `W/"abc"`, notModified: true},
+		{name: "ETag List", header: "If-None-Match", value: `"old", "abc"`, notModified: true},
+		{name: "Any ETag", header: "If-None-Match", value: "*", notModified: true},
+		{name: "Other ETag", header: "If-None-Match", value: `"old"`},
+		{name: "Not Modified Since", header: "If-Modified-Since", value: "Wed, 10 Jan 2024 05:00:00 GMT", notModified: true},
+		{name: "Modified Since", header: "If-Modified-Since", value: "Wed, 10 Jan 2024 04:59:59 GMT"},
+		{name: "Invalid Date", header: "If-Modified-Since", value: "yesterday"},
+		{name: "Unsafe Method", method: http.MethodPost, header: "If-None-Match", value: `"abc"`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/journal/2024-01-10", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+
+			require.Equal(t, tc.notModified, http_cache.NotModified(rr, req, etag, lastModified))
+			require.Equal(t, etag, rr.Header().Get("ETag"))
+			require.Equal(t, "Wed, 10 Jan 2024 05:00:00 GMT", rr.Header().Get("Last-Modified"))
+			if tc.notModified {
+				require.Equal(t, http.StatusNotModified, rr.Code)
+				require.Empty(t, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
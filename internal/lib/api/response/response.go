@@ -1,8 +1,19 @@
 package response
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"mime"
+	"net/http"
+	"strings"
+)
+
 type Response struct {
 	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Error  *Error `json:"error,omitempty"`
 }
 
 const (
@@ -10,15 +21,170 @@ const (
 	StatusError = "Error"
 )
 
+// Error codes are stable identifiers clients can switch on; messages are meant
+// for humans and may change.
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeNotFound         = "not_found"
+	CodeInternal         = "internal_error"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeConflict         = "conflict"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeRateLimited      = "rate_limited"
+)
+
+// ContentTypeProblem is the media type of RFC 7807 problem details. Errors are
+// rendered as problem details when the client accepts it.
+const ContentTypeProblem = "application/problem+json"
+
+// Error describes why a request failed.
+type Error struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
 func OK() Response {
 	return Response{
 		Status: StatusOK,
 	}
 }
 
-func Error(msg string) Response {
-	return Response{
+// HTTPError is an error reported to the client with the given status. Err, the
+// underlying cause, is never exposed.
+type HTTPError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]string
+	Err     error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidParameter reports an invalid request parameter.
+func InvalidParameter(param, msg string) *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeInvalidParameter,
+		Message: msg,
+		Details: map[string]string{"parameter": param},
+	}
+}
+
+func NotFound(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusNotFound, Code: CodeNotFound, Message: msg}
+}
+
+// Unauthorized reports a missing, unknown or revoked API key.
+func Unauthorized(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: msg}
+}
+
+// Forbidden reports a valid API key that may not use the route.
+func Forbidden(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusForbidden, Code: CodeForbidden, Message: msg}
+}
+
+// Conflict reports a request that conflicts with the current state, such as
+// creating an entry that exists.
+func Conflict(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusConflict, Code: CodeConflict, Message: msg}
+}
+
+// QuotaExceeded reports an API key that used up its daily quota.
+func QuotaExceeded(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusTooManyRequests, Code: CodeQuotaExceeded, Message: msg}
+}
+
+// RateLimited reports a client that sends requests faster than it may.
+func RateLimited(msg string) *HTTPError {
+	return &HTTPError{Status: http.StatusTooManyRequests, Code: CodeRateLimited, Message: msg}
+}
+
+func Internal(msg string, err error) *HTTPError {
+	return &HTTPError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: msg, Err: err}
+}
+
+// FromError returns the HTTPError err is reported as. Errors other than an
+// HTTPError are internal ones; handlers map the errors of their dependencies.
+func FromError(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	return Internal("internal error", err)
+}
+
+// Problem is an RFC 7807 problem details object extended with the fields of
+// Error.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// RenderError writes err with the status and code FromError maps it to, as
+// problem details if the client accepts them and in the response envelope
+// otherwise.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	httpErr := FromError(err)
+	requestID := middleware.GetReqID(r.Context())
+
+	if acceptsProblem(r) {
+		w.Header().Set("Content-Type", ContentTypeProblem)
+		w.WriteHeader(httpErr.Status)
+		_ = json.NewEncoder(w).Encode(Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(httpErr.Status),
+			Status:    httpErr.Status,
+			Detail:    httpErr.Message,
+			Instance:  r.URL.Path,
+			Code:      httpErr.Code,
+			RequestID: requestID,
+			Details:   httpErr.Details,
+		})
+
+		return
+	}
+
+	render.Status(r, httpErr.Status)
+	render.JSON(w, r, Response{
 		Status: StatusError,
-		Error:  msg,
+		Error: &Error{
+			Code:      httpErr.Code,
+			Message:   httpErr.Message,
+			RequestID: requestID,
+			Details:   httpErr.Details,
+		},
+	})
+}
+
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ContentTypeProblem {
+			return true
+		}
 	}
+
+	return false
 }
@@ -0,0 +1,84 @@
+package response_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	resp "stellar_journal/internal/lib/api/response"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromError(t *testing.T) {
+	cases := []struct {
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{resp.InvalidParameter("limit", "invalid limit"), http.StatusBadRequest, resp.CodeInvalidParameter, "invalid limit"},
+		{fmt.Errorf("get: %w", resp.NotFound("apod not found")), http.StatusNotFound, resp.CodeNotFound, "apod not found"},
+		{errors.New("connection refused"), http.StatusInternalServerError, resp.CodeInternal, "internal error"},
+	}
+
+	for _, tc := range cases {
+		httpErr := resp.FromError(tc.err)
+
+		require.Equal(t, tc.status, httpErr.Status, tc.err)
+		require.Equal(t, tc.code, httpErr.Code, tc.err)
+		require.Equal(t, tc.message, httpErr.Message, tc.err)
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp.RenderError(w, r, resp.InvalidParameter("limit", "invalid limit"))
+	}))
+
+	t.Run("Envelope", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/journal?limit=abc", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+
+		var body resp.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+		require.Equal(t, resp.StatusError, body.Status)
+		require.NotNil(t, body.Error)
+		require.Equal(t, resp.CodeInvalidParameter, body.Error.Code)
+		require.Equal(t, "invalid limit", body.Error.Message)
+		require.Equal(t, map[string]string{"parameter": "limit"}, body.Error.Details)
+		require.NotEmpty(t, body.Error.RequestID)
+	})
+
+	t.Run("Problem", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/journal?limit=abc", nil)
+		req.Header.Set("Accept", "application/problem+json, application/json;q=0.5")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, resp.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+		var problem resp.Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+
+		require.Equal(t, http.StatusBadRequest, problem.Status)
+		require.Equal(t, "Bad Request", problem.Title)
+		require.Equal(t, "invalid limit", problem.Detail)
+		require.Equal(t, "/journal", problem.Instance)
+		require.Equal(t, resp.CodeInvalidParameter, problem.Code)
+		require.NotEmpty(t, problem.RequestID)
+	})
+}
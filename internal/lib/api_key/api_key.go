@@ -0,0 +1,44 @@
+package api_key
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	// keyPrefix marks the keys of the service, so that leaked keys are easy to
+	// find with secret scanners.
+	keyPrefix = "sj_"
+	// PrefixLen is the length of the start of a key shown in listings.
+	PrefixLen = len(keyPrefix) + 8
+)
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	const op = "internal/lib/api_key.Generate"
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%s: failed to read random bytes: %w", op, err)
+	}
+
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hash a key is stored and looked up by. Keys are random and
+// long, so a fast unsalted hash is enough.
+func Hash(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// Prefix returns the start of the key that identifies it in listings.
+func Prefix(key string) string {
+	if len(key) < PrefixLen {
+		return key
+	}
+
+	return key[:PrefixLen]
+}
@@ -0,0 +1,27 @@
+package api_key_test
+
+import (
+	"stellar_journal/internal/lib/api_key"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	key, err := api_key.Generate()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, "sj_"))
+	require.Len(t, key, 46)
+
+	other, err := api_key.Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+	require.NotEqual(t, api_key.Hash(key), api_key.Hash(other))
+	require.Equal(t, api_key.Hash(key), api_key.Hash(key))
+}
+
+func TestPrefix(t *testing.T) {
+	require.Equal(t, "sj_abcdefgh", api_key.Prefix("sj_abcdefghijklmnop"))
+	require.Equal(t, "short", api_key.Prefix("short"))
+}
@@ -0,0 +1,181 @@
+package apod_date
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+)
+
+// Layout is the date format used by the NASA API and in our URLs.
+const Layout = "2006-01-02"
+
+var (
+	ErrInvalidFormat = errors.New("invalid date format, expected YYYY-MM-DD")
+	ErrBeforeEpoch   = errors.New("date is before the first APOD")
+	ErrInFuture      = errors.New("date is in the future")
+)
+
+// Epoch is the date of the very first Astronomy Picture of the Day.
+var Epoch = New(1995, time.June, 16)
+
+// Location is the time zone NASA publishes new pictures in (at midnight).
+var Location = mustLoadLocation("America/New_York")
+
+// Date is a calendar day without time or zone, stored as midnight UTC. It is
+// encoded as YYYY-MM-DD in JSON and SQL. The zero Date means "no date".
+type Date struct {
+	t time.Time
+}
+
+func New(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// FromTime returns the calendar day of t in t's location.
+func FromTime(t time.Time) Date {
+	return New(t.Date())
+}
+
+// Today returns the current APOD day, i.e. the current date in Location.
+func Today() Date {
+	return FromTime(time.Now().In(Location))
+}
+
+func Parse(s string) (Date, error) {
+	t, err := time.Parse(Layout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+
+	return Date{t: t}, nil
+}
+
+func MustParse(s string) Date {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.t.Format(Layout)
+}
+
+// Time returns midnight UTC of the day.
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+func (d Date) Equal(other Date) bool {
+	return d.t.Equal(other.t)
+}
+
+func (d Date) Before(other Date) bool {
+	return d.t.Before(other.t)
+}
+
+func (d Date) After(other Date) bool {
+	return d.t.After(other.t)
+}
+
+func (d Date) AddDays(days int) Date {
+	return Date{t: d.t.AddDate(0, 0, days)}
+}
+
+// DaysUntil returns the number of days from d to other.
+func (d Date) DaysUntil(other Date) int {
+	return int(other.t.Sub(d.t).Hours() / 24)
+}
+
+// Validate checks that an APOD can exist for the date, given the current day.
+func (d Date) Validate(today Date) error {
+	if d.Before(Epoch) {
+		return fmt.Errorf("%w (%s)", ErrBeforeEpoch, Epoch)
+	}
+	if d.After(today) {
+		return ErrInFuture
+	}
+
+	return nil
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, data)
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// Value implements driver.Valuer. The zero Date is stored as NULL.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner for DATE columns.
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = New(v.Date())
+	case string:
+		return d.UnmarshalText([]byte(v[:min(len(v), len(Layout))]))
+	case []byte:
+		return d.UnmarshalText(v[:min(len(v), len(Layout))])
+	default:
+		return fmt.Errorf("apod_date: cannot scan %T into Date", src)
+	}
+
+	return nil
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
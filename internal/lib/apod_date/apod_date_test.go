@@ -0,0 +1,58 @@
+package apod_date_test
+
+import (
+	"encoding/json"
+	"stellar_journal/internal/lib/apod_date"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	d, err := apod_date.Parse("2024-02-29")
+	require.NoError(t, err)
+	require.Equal(t, "2024-02-29", d.String())
+
+	for _, s := range []string{"", "2024-2-29", "2023-02-29", "29-02-2024", "2024-02-29T00:00:00Z"} {
+		_, err := apod_date.Parse(s)
+		require.ErrorIs(t, err, apod_date.ErrInvalidFormat, s)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	today := apod_date.MustParse("2024-01-10")
+
+	require.NoError(t, apod_date.Epoch.Validate(today))
+	require.NoError(t, today.Validate(today))
+	require.ErrorIs(t, apod_date.MustParse("1995-06-15").Validate(today), apod_date.ErrBeforeEpoch)
+	require.ErrorIs(t, today.AddDays(1).Validate(today), apod_date.ErrInFuture)
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Date apod_date.Date `json:"date"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"date":"2024-01-10"}`), &v))
+	require.Equal(t, apod_date.New(2024, time.January, 10), v.Date)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"date":"2024-01-10"}`, string(data))
+
+	require.Error(t, json.Unmarshal([]byte(`{"date":"10.01.2024"}`), &v))
+}
+
+func TestScan(t *testing.T) {
+	var d apod_date.Date
+
+	require.NoError(t, d.Scan(time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, "2024-01-10", d.String())
+
+	require.NoError(t, d.Scan([]byte("2024-01-11T00:00:00Z")))
+	require.Equal(t, "2024-01-11", d.String())
+
+	require.NoError(t, d.Scan(nil))
+	require.True(t, d.IsZero())
+}
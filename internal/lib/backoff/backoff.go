@@ -0,0 +1,37 @@
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how failed operations are retried. The delay before retry n
+// is Initial * Multiplier^(n-1), capped at Max and shortened by a random
+// fraction of up to Jitter. MaxAttempts caps the number of attempts, including
+// the first one; zero means no cap.
+type Policy struct {
+	Initial     time.Duration
+	Max         time.Duration
+	Multiplier  float64
+	Jitter      float64
+	MaxAttempts int
+}
+
+// Delay returns the wait before the given retry, starting at 1.
+func (b Policy) Delay(retry int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(retry-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d -= d * min(b.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
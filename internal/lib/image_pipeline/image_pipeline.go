@@ -0,0 +1,116 @@
+package image_pipeline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+
+	jpegQuality = 82
+)
+
+// ErrTooManyPixels is returned by Decode for images larger than it accepts.
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// CheckFormat fails for formats Encode does not support, so that configured
+// formats can be checked before any image is archived.
+func CheckFormat(format string) error {
+	const op = "internal/lib/image_pipeline.CheckFormat"
+
+	switch format {
+	case FormatJPEG, FormatWebP:
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported format %q, expected %s or %s", op, format, FormatJPEG, FormatWebP)
+	}
+}
+
+// IsRasterType reports whether mediaType, without parameters, is one of the
+// raster image types the journal archives and serves. Other types, such as
+// SVG or HTML, could run scripts when served from the journal's origin.
+func IsRasterType(mediaType string) bool {
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	default:
+		return false
+	}
+}
+
+// ContentType returns the MIME type of an output format.
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Decode reads a JPEG, PNG or GIF image of up to maxPixels pixels, or of any
+// size when maxPixels is not positive. The size is read from the header before
+// decoding, so that a small file cannot claim gigabytes of memory.
+func Decode(r io.Reader, maxPixels int64) (image.Image, error) {
+	const op = "internal/lib/image_pipeline.Decode"
+
+	var header bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to decode image header: %w", op, err)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); maxPixels > 0 && pixels > maxPixels {
+		return nil, fmt.Errorf("%s: %dx%d image: %w", op, cfg.Width, cfg.Height, ErrTooManyPixels)
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to decode image: %w", op, err)
+	}
+
+	return img, nil
+}
+
+// Resize scales src to the given width, keeping the aspect ratio.
+func Resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// Encode writes img in the given format. WebP output is lossless, so it is
+// usually larger than JPEG for photographs.
+func Encode(w io.Writer, img image.Image, format string) error {
+	const op = "internal/lib/image_pipeline.Encode"
+
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatWebP:
+		err = nativewebp.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("%s: unsupported format %q", op, format)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode %s: %w", op, format, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package image_pipeline_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"stellar_journal/internal/lib/image_pipeline"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	_ "golang.org/x/image/webp"
+)
+
+func TestPipeline(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 600; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	_, err := image_pipeline.Decode(bytes.NewReader(buf.Bytes()), 800*600-1)
+	require.ErrorIs(t, err, image_pipeline.ErrTooManyPixels)
+
+	img, err := image_pipeline.Decode(&buf, 800*600)
+	require.NoError(t, err)
+
+	resized := image_pipeline.Resize(img, 320)
+	require.Equal(t, image.Rect(0, 0, 320, 240), resized.Bounds())
+
+	for _, format := range []string{image_pipeline.FormatJPEG, image_pipeline.FormatWebP} {
+		var out bytes.Buffer
+		require.NoError(t, image_pipeline.Encode(&out, resized, format))
+		require.Equal(t, image_pipeline.ContentType(format), "image/"+format)
+
+		decoded, decodedFormat, err := image.Decode(&out)
+		require.NoError(t, err)
+		require.Equal(t, format, decodedFormat)
+		require.Equal(t, resized.Bounds(), decoded.Bounds())
+		require.NoError(t, image_pipeline.CheckFormat(format))
+	}
+
+	require.Error(t, image_pipeline.Encode(&bytes.Buffer{}, resized, "bmp"))
+	require.Error(t, image_pipeline.CheckFormat("bmp"))
+
+	require.True(t, image_pipeline.IsRasterType(image_pipeline.ContentType(image_pipeline.FormatWebP)))
+	require.True(t, image_pipeline.IsRasterType("image/png"))
+	require.False(t, image_pipeline.IsRasterType("image/svg+xml"))
+	require.False(t, image_pipeline.IsRasterType("text/html"))
+}
@@ -0,0 +1,72 @@
+package video_embed
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	ProviderYouTube = "youtube"
+	ProviderVimeo   = "vimeo"
+)
+
+// Video is a video hosted by a known provider.
+type Video struct {
+	Provider string
+	ID       string
+}
+
+// Parse recognizes YouTube and Vimeo embed, watch and short links, including the
+// protocol-relative "//www.youtube.com/embed/..." form used by APOD.
+func Parse(rawURL string) (Video, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return Video{}, false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	var v Video
+	switch host {
+	case "youtube.com", "m.youtube.com", "youtube-nocookie.com":
+		v.Provider = ProviderYouTube
+		switch {
+		case len(segments) == 2 && (segments[0] == "embed" || segments[0] == "v" || segments[0] == "shorts"):
+			v.ID = segments[1]
+		case len(segments) == 1 && segments[0] == "watch":
+			v.ID = u.Query().Get("v")
+		}
+	case "youtu.be":
+		v.Provider = ProviderYouTube
+		if len(segments) == 1 {
+			v.ID = segments[0]
+		}
+	case "player.vimeo.com":
+		v.Provider = ProviderVimeo
+		if len(segments) == 2 && segments[0] == "video" {
+			v.ID = segments[1]
+		}
+	case "vimeo.com":
+		v.Provider = ProviderVimeo
+		if len(segments) == 1 {
+			v.ID = segments[0]
+		}
+	}
+
+	if v.ID == "" {
+		return Video{}, false
+	}
+
+	return v, true
+}
+
+// ThumbnailURL returns the provider's default thumbnail of the video, if it has
+// a predictable one.
+func (v Video) ThumbnailURL() string {
+	if v.Provider == ProviderYouTube {
+		return "https://img.youtube.com/vi/" + v.ID + "/hqdefault.jpg"
+	}
+
+	return ""
+}
@@ -0,0 +1,33 @@
+package video_embed_test
+
+import (
+	"stellar_journal/internal/lib/video_embed"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		url      string
+		provider string
+		id       string
+	}{
+		{url: "https://www.youtube.com/embed/1R5QqhPq1Ik?rel=0", provider: video_embed.ProviderYouTube, id: "1R5QqhPq1Ik"},
+		{url: "//www.youtube.com/embed/1R5QqhPq1Ik", provider: video_embed.ProviderYouTube, id: "1R5QqhPq1Ik"},
+		{url: "https://www.youtube.com/watch?v=1R5QqhPq1Ik", provider: video_embed.ProviderYouTube, id: "1R5QqhPq1Ik"},
+		{url: "https://youtu.be/1R5QqhPq1Ik", provider: video_embed.ProviderYouTube, id: "1R5QqhPq1Ik"},
+		{url: "https://player.vimeo.com/video/123456789?color=white", provider: video_embed.ProviderVimeo, id: "123456789"},
+		{url: "https://vimeo.com/123456789", provider: video_embed.ProviderVimeo, id: "123456789"},
+		{url: "https://apod.nasa.gov/apod/image/2401/video.mp4"},
+		{url: "https://www.youtube.com/channel/abc"},
+		{url: ""},
+	}
+
+	for _, tc := range cases {
+		v, ok := video_embed.Parse(tc.url)
+		require.Equal(t, tc.id != "", ok, tc.url)
+		require.Equal(t, tc.provider, v.Provider, tc.url)
+		require.Equal(t, tc.id, v.ID, tc.url)
+	}
+}
@@ -0,0 +1,295 @@
+package media_archiver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"stellar_journal/internal/blob_store"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/image_pipeline"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"strings"
+	"time"
+)
+
+const mediaTypeImage = "image"
+
+var (
+	// ErrTooLarge is returned when an image is larger than the archiver
+	// accepts.
+	ErrTooLarge = errors.New("image is too large")
+	// ErrNotImage is returned when a download is not a raster image.
+	ErrNotImage = errors.New("not a raster image")
+)
+
+type Storage interface {
+	SaveAPODMedia(ctx context.Context, media *stellar_journal_models.APODMedia) error
+	SaveAPODDerivative(ctx context.Context, derivative *stellar_journal_models.APODDerivative) error
+}
+
+// Derivatives configures the resized copies generated for every archived image.
+// Widths larger than the source image are skipped, and so are images of more
+// than MaxPixels pixels, which are archived without derivatives.
+type Derivatives struct {
+	Widths    []int
+	Formats   []string
+	MaxPixels int64
+}
+
+// Archiver downloads the images of an APOD into a blob store and records them
+// in the storage.
+type Archiver struct {
+	client      *http.Client
+	blobs       blob_store.Store
+	storage     Storage
+	logger      *slog.Logger
+	maxSize     int64
+	derivatives Derivatives
+}
+
+// NewArchiver returns an archiver whose downloads take up to timeout and
+// maxSize bytes each.
+func NewArchiver(blobs blob_store.Store, storage Storage, logger *slog.Logger, timeout time.Duration, maxSize int64, derivatives Derivatives) *Archiver {
+	widths := slices.Clone(derivatives.Widths)
+	slices.Sort(widths)
+	derivatives.Widths = widths
+
+	return &Archiver{
+		client:      &http.Client{Timeout: timeout},
+		blobs:       blobs,
+		storage:     storage,
+		logger:      logger,
+		maxSize:     maxSize,
+		derivatives: derivatives,
+	}
+}
+
+// Archive stores the SD (url) and HD (hdurl) variants of an image APOD and
+// generates derivatives from the best of them. Other media types are skipped.
+func (a *Archiver) Archive(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	const op = "internal/media_archiver.Archive"
+
+	if apod.MediaType != mediaTypeImage {
+		return nil
+	}
+
+	variants := []struct {
+		name string
+		url  string
+	}{
+		{stellar_journal_models.MediaVariantSD, apod.Url},
+		{stellar_journal_models.MediaVariantHD, apod.Hdurl},
+	}
+
+	var source *stellar_journal_models.APODMedia
+	for _, v := range variants {
+		if v.url == "" {
+			continue
+		}
+
+		media, err := a.download(ctx, apod, v.name, v.url)
+		if err != nil {
+			return fmt.Errorf("%s: failed to archive %s image: %w", op, v.name, err)
+		}
+
+		if err := a.storage.SaveAPODMedia(ctx, media); err != nil {
+			return fmt.Errorf("%s: failed to save %s image: %w", op, v.name, err)
+		}
+
+		a.logger.Info(
+			"APOD image archived",
+			slog.String("date", apod.Date.String()),
+			slog.String("variant", v.name),
+			slog.Int64("bytes", media.ByteSize),
+		)
+		source = media
+	}
+
+	if source == nil || len(a.derivatives.Widths) == 0 {
+		return nil
+	}
+
+	if err := a.generateDerivatives(ctx, source); err != nil {
+		return fmt.Errorf("%s: failed to generate derivatives: %w", op, err)
+	}
+
+	return nil
+}
+
+func (a *Archiver) generateDerivatives(ctx context.Context, source *stellar_journal_models.APODMedia) error {
+	blob, err := a.blobs.Get(source.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source.StorageKey, err)
+	}
+	defer func(blob io.ReadCloser) {
+		_ = blob.Close()
+	}(blob)
+
+	img, err := image_pipeline.Decode(blob, a.derivatives.MaxPixels)
+	if errors.Is(err, image_pipeline.ErrTooManyPixels) {
+		a.logger.Warn(
+			"APOD image too large for derivatives",
+			slog.String("date", source.Date.String()),
+			sl.Err(err),
+		)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, width := range a.derivatives.Widths {
+		if width >= img.Bounds().Dx() {
+			break
+		}
+		resized := image_pipeline.Resize(img, width)
+
+		for _, format := range a.derivatives.Formats {
+			var buf bytes.Buffer
+			if err := image_pipeline.Encode(&buf, resized, format); err != nil {
+				return err
+			}
+
+			sum := sha256.Sum256(buf.Bytes())
+			derivative := &stellar_journal_models.APODDerivative{
+				Source:      source.Source,
+				Date:        source.Date,
+				Width:       width,
+				Format:      format,
+				StorageKey:  fmt.Sprintf("%s/%dw.%s", keyPrefix(source.Source, source.Date), width, format),
+				Checksum:    hex.EncodeToString(sum[:]),
+				ByteSize:    int64(buf.Len()),
+				ContentType: image_pipeline.ContentType(format),
+			}
+
+			if err := a.blobs.Put(derivative.StorageKey, &buf); err != nil {
+				return fmt.Errorf("failed to store %s: %w", derivative.StorageKey, err)
+			}
+			if err := a.storage.SaveAPODDerivative(ctx, derivative); err != nil {
+				return fmt.Errorf("failed to save %s: %w", derivative.StorageKey, err)
+			}
+		}
+	}
+
+	a.logger.Info("APOD image derivatives generated", slog.String("date", source.Date.String()))
+
+	return nil
+}
+
+func (a *Archiver) download(ctx context.Context, apod *stellar_journal_models.APOD, variant, rawURL string) (*stellar_journal_models.APODMedia, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", rawURL, err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status code: %d", rawURL, resp.StatusCode)
+	}
+	if resp.ContentLength > a.maxSize {
+		return nil, fmt.Errorf("failed to download %s: %d bytes: %w", rawURL, resp.ContentLength, ErrTooLarge)
+	}
+
+	// Servers may send more than they announce, or announce nothing.
+	body := bufio.NewReader(&limitedReader{r: resp.Body, n: a.maxSize})
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType == "application/octet-stream" {
+		sniff, _ := body.Peek(512)
+		contentType = http.DetectContentType(sniff)
+	}
+	// The image route serves the stored type from the journal's origin.
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !image_pipeline.IsRasterType(mediaType) {
+		return nil, fmt.Errorf("failed to download %s: %q: %w", rawURL, contentType, ErrNotImage)
+	}
+
+	key := fmt.Sprintf("%s/%s%s", keyPrefix(apod.Source, apod.Date), variant, extension(rawURL))
+	hash := sha256.New()
+	counter := &countingWriter{}
+
+	if err := a.blobs.Put(key, io.TeeReader(body, io.MultiWriter(hash, counter))); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return &stellar_journal_models.APODMedia{
+		Source:      apod.Source,
+		Date:        apod.Date,
+		Variant:     variant,
+		SourceURL:   rawURL,
+		StorageKey:  key,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ByteSize:    counter.n,
+		ContentType: contentType,
+	}, nil
+}
+
+// keyPrefix returns the blob store directory of the images of an APOD. Images
+// of NASA APODs are kept directly under their date, as they were before other
+// sources were added.
+func keyPrefix(source string, date apod_date.Date) string {
+	if source == "" || source == stellar_journal_models.SourceNASAAPOD {
+		return date.String()
+	}
+
+	return source + "/" + date.String()
+}
+
+func extension(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+// limitedReader reads up to n bytes from r and fails with ErrTooLarge when r
+// has more, unlike io.LimitReader, which would truncate the image silently.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n + int(l.n), ErrTooLarge
+	}
+
+	return n, err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,227 @@
+package media_archiver_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/blob_store/filesystem"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/media_archiver"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStorage struct {
+	mock.Mock
+}
+
+func (m *MockStorage) SaveAPODMedia(ctx context.Context, media *stellar_journal_models.APODMedia) error {
+	args := m.Called(ctx, media)
+	return args.Error(0)
+}
+
+func (m *MockStorage) SaveAPODDerivative(ctx context.Context, derivative *stellar_journal_models.APODDerivative) error {
+	args := m.Called(ctx, derivative)
+	return args.Error(0)
+}
+
+var derivatives = media_archiver.Derivatives{
+	Widths:  []int{1280, 320, 640},
+	Formats: []string{"jpeg", "webp"},
+}
+
+func TestArchiver_Archive(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n fake png")
+
+	var hdImage bytes.Buffer
+	require.NoError(t, jpeg.Encode(&hdImage, image.NewGray(image.Rect(0, 0, 700, 350)), nil))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image/hd.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write(hdImage.Bytes())
+		case "/image/page.png":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<script>alert(1)</script>"))
+		case "/image/drawing.svg":
+			_, _ = w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+		case "/image/unannounced.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(png)
+		case "/image/sd.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(png)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	blobs, err := filesystem.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	t.Run("ArchivesBothVariants", func(t *testing.T) {
+		st := new(MockStorage)
+		var saved []*stellar_journal_models.APODMedia
+		st.On("SaveAPODMedia", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = append(saved, args.Get(1).(*stellar_journal_models.APODMedia))
+		}).Return(nil).Twice()
+		var generated []string
+		st.On("SaveAPODDerivative", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			generated = append(generated, args.Get(1).(*stellar_journal_models.APODDerivative).StorageKey)
+		}).Return(nil)
+
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, derivatives)
+		err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+			Date:      apod_date.MustParse("2024-01-01"),
+			MediaType: "image",
+			Url:       srv.URL + "/image/sd.png",
+			Hdurl:     srv.URL + "/image/hd.jpg",
+		})
+		require.NoError(t, err)
+		st.AssertExpectations(t)
+
+		require.Len(t, saved, 2)
+		sd, hd := saved[0], saved[1]
+
+		sum := sha256.Sum256(png)
+		require.Equal(t, stellar_journal_models.MediaVariantSD, sd.Variant)
+		require.Equal(t, "2024-01-01/sd.png", sd.StorageKey)
+		require.Equal(t, "image/png", sd.ContentType)
+		require.Equal(t, hex.EncodeToString(sum[:]), sd.Checksum)
+		require.Equal(t, int64(len(png)), sd.ByteSize)
+
+		require.Equal(t, "2024-01-01/hd.jpg", hd.StorageKey)
+		require.Equal(t, "image/jpeg", hd.ContentType)
+
+		blob, err := blobs.Get(hd.StorageKey)
+		require.NoError(t, err)
+		defer blob.Close()
+		data, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		require.Equal(t, hdImage.Bytes(), data)
+
+		require.Equal(t, []string{
+			"2024-01-01/320w.jpeg", "2024-01-01/320w.webp",
+			"2024-01-01/640w.jpeg", "2024-01-01/640w.webp",
+		}, generated)
+	})
+
+	t.Run("SkipsDerivativesOfHugeImages", func(t *testing.T) {
+		st := new(MockStorage)
+		st.On("SaveAPODMedia", mock.Anything, mock.Anything).Return(nil).Once()
+
+		huge := derivatives
+		huge.MaxPixels = 700*350 - 1
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, huge)
+		err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+			Date:      apod_date.MustParse("2024-01-05"),
+			MediaType: "image",
+			Hdurl:     srv.URL + "/image/hd.jpg",
+		})
+		require.NoError(t, err)
+		st.AssertExpectations(t)
+		st.AssertNotCalled(t, "SaveAPODDerivative", mock.Anything, mock.Anything)
+	})
+
+	t.Run("PrefixesKeysOfOtherSources", func(t *testing.T) {
+		st := new(MockStorage)
+		var saved *stellar_journal_models.APODMedia
+		st.On("SaveAPODMedia", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*stellar_journal_models.APODMedia)
+		}).Return(nil).Once()
+
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, media_archiver.Derivatives{})
+		err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+			Source:    stellar_journal_models.SourceBing,
+			Date:      apod_date.MustParse("2024-01-01"),
+			MediaType: "image",
+			Url:       srv.URL + "/image/sd.png",
+		})
+		require.NoError(t, err)
+		require.Equal(t, stellar_journal_models.SourceBing, saved.Source)
+		require.Equal(t, "bing/2024-01-01/sd.png", saved.StorageKey)
+	})
+
+	t.Run("SkipsVideos", func(t *testing.T) {
+		st := new(MockStorage)
+
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, derivatives)
+		err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{MediaType: "video", Url: "https://www.youtube.com/embed/x"})
+		require.NoError(t, err)
+		st.AssertNotCalled(t, "SaveAPODMedia", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DownloadFails", func(t *testing.T) {
+		st := new(MockStorage)
+
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, derivatives)
+		err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+			Date:      apod_date.MustParse("2024-01-02"),
+			MediaType: "image",
+			Url:       srv.URL + "/missing.jpg",
+		})
+		require.Error(t, err)
+		st.AssertNotCalled(t, "SaveAPODMedia", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectsOtherTypes", func(t *testing.T) {
+		for _, path := range []string{"/image/page.png", "/image/drawing.svg"} {
+			st := new(MockStorage)
+
+			archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, derivatives)
+			err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+				Date:      apod_date.MustParse("2024-01-05"),
+				MediaType: "image",
+				Url:       srv.URL + path,
+			})
+			require.ErrorIs(t, err, media_archiver.ErrNotImage, path)
+			st.AssertNotCalled(t, "SaveAPODMedia", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		for _, path := range []string{"/image/hd.jpg", "/image/unannounced.png"} {
+			st := new(MockStorage)
+
+			archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, int64(len(png))-1, derivatives)
+			err := archiver.Archive(context.Background(), &stellar_journal_models.APOD{
+				Date:      apod_date.MustParse("2024-01-04"),
+				MediaType: "image",
+				Url:       srv.URL + path,
+			})
+			require.ErrorIs(t, err, media_archiver.ErrTooLarge, path)
+			st.AssertNotCalled(t, "SaveAPODMedia", mock.Anything, mock.Anything)
+
+			_, err = blobs.Get("2024-01-04/sd" + path[len(path)-4:])
+			require.Error(t, err, "nothing is stored")
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		st := new(MockStorage)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		archiver := media_archiver.NewArchiver(blobs, st, slogdiscard.NewDiscardLogger(), time.Second, 1<<20, derivatives)
+		err := archiver.Archive(ctx, &stellar_journal_models.APOD{
+			Date:      apod_date.MustParse("2024-01-03"),
+			MediaType: "image",
+			Url:       srv.URL + "/image/sd.png",
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		st.AssertNotCalled(t, "SaveAPODMedia", mock.Anything, mock.Anything)
+	})
+}
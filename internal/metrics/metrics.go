@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/stellar_api/nasa_api"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "stellar_journal"
+
+type WorkerStats interface {
+	Stats() apod_worker.Stats
+}
+
+type RateLimiter interface {
+	RateLimit() nasa_api.RateLimit
+}
+
+// Metrics is the registry served at /metrics. Request and query metrics are
+// observed as they happen; the pool, worker and rate limit metrics are read
+// from their sources on every scrape.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	workers         *workerCollector
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time storage methods spent in the database.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+		workers: &workerCollector{workers: make(map[string]WorkerStats)},
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.workers,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a served request. route is the chi route pattern, not
+// the path, so that the number of series stays bounded.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery records the duration of a storage method. It is a
+// postgresql.QueryObserver.
+func (m *Metrics) ObserveQuery(method string, duration time.Duration) {
+	m.queryDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// RegisterDB exposes the connection pool statistics of db as go_sql_* gauges
+// and counters.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterWorker exposes the run counters and the last success of the worker
+// of source.
+func (m *Metrics) RegisterWorker(source string, worker WorkerStats) {
+	m.workers.add(source, worker)
+}
+
+// RegisterRateLimit exposes the NASA API quota. Nothing is reported until a
+// response carried the rate limit headers.
+func (m *Metrics) RegisterRateLimit(limiter RateLimiter) error {
+	return m.registry.Register(&rateLimitCollector{limiter: limiter})
+}
+
+var (
+	workerRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "worker", "runs_total"),
+		"Worker runs by source and result; retries count as runs.",
+		[]string{"source", "result"}, nil,
+	)
+	workerLastSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "worker", "last_success_timestamp_seconds"),
+		"Start of the last successful worker run.",
+		[]string{"source"}, nil,
+	)
+	rateLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nasa_api", "rate_limit"),
+		"Hourly request quota of the NASA API key.",
+		nil, nil,
+	)
+	rateLimitRemainingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nasa_api", "rate_limit_remaining"),
+		"Requests left in the NASA API quota as of the latest response.",
+		nil, nil,
+	)
+)
+
+type workerCollector struct {
+	mu      sync.Mutex
+	workers map[string]WorkerStats
+}
+
+func (c *workerCollector) add(source string, worker WorkerStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.workers[source] = worker
+}
+
+func (c *workerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workerRunsDesc
+	ch <- workerLastSuccessDesc
+}
+
+func (c *workerCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for source, worker := range c.workers {
+		stats := worker.Stats()
+		ch <- prometheus.MustNewConstMetric(workerRunsDesc, prometheus.CounterValue, float64(stats.Successes), source, "success")
+		ch <- prometheus.MustNewConstMetric(workerRunsDesc, prometheus.CounterValue, float64(stats.Failures), source, "failure")
+		if !stats.LastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(workerLastSuccessDesc, prometheus.GaugeValue, float64(stats.LastSuccess.UnixNano())/1e9, source)
+		}
+	}
+}
+
+type rateLimitCollector struct {
+	limiter RateLimiter
+}
+
+func (c *rateLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rateLimitDesc
+	ch <- rateLimitRemainingDesc
+}
+
+func (c *rateLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	limit := c.limiter.RateLimit()
+	if limit.UpdatedAt.IsZero() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(rateLimitDesc, prometheus.GaugeValue, float64(limit.Limit))
+	ch <- prometheus.MustNewConstMetric(rateLimitRemainingDesc, prometheus.GaugeValue, float64(limit.Remaining))
+}
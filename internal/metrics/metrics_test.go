@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/apod_worker"
+	"stellar_journal/internal/metrics"
+	"stellar_journal/internal/stellar_api/nasa_api"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type workerStats apod_worker.Stats
+
+func (s workerStats) Stats() apod_worker.Stats { return apod_worker.Stats(s) }
+
+type rateLimiter nasa_api.RateLimit
+
+func (l rateLimiter) RateLimit() nasa_api.RateLimit { return nasa_api.RateLimit(l) }
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	return rr.Body.String()
+}
+
+func TestObservations(t *testing.T) {
+	m := metrics.New()
+	m.ObserveRequest(http.MethodGet, "/journal/{date}", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/journal/{date}", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/journal/{date}", http.StatusNotFound, time.Millisecond)
+	m.ObserveQuery("GetAPOD", 3*time.Millisecond)
+
+	body := scrape(t, m)
+	require.Contains(t, body, `stellar_journal_http_requests_total{method="GET",route="/journal/{date}",status="200"} 2`)
+	require.Contains(t, body, `stellar_journal_http_requests_total{method="GET",route="/journal/{date}",status="404"} 1`)
+	require.Contains(t, body, `stellar_journal_http_request_duration_seconds_count{method="GET",route="/journal/{date}",status="200"} 2`)
+	require.Contains(t, body, `stellar_journal_db_query_duration_seconds_count{method="GetAPOD"} 1`)
+}
+
+func TestWorkerMetrics(t *testing.T) {
+	m := metrics.New()
+	m.RegisterWorker("nasa_apod", workerStats{Successes: 3, Failures: 1, LastSuccess: time.Unix(1704171900, 0)})
+	m.RegisterWorker("bing", workerStats{Failures: 2})
+
+	body := scrape(t, m)
+	require.Contains(t, body, `stellar_journal_worker_runs_total{result="success",source="nasa_apod"} 3`)
+	require.Contains(t, body, `stellar_journal_worker_runs_total{result="failure",source="nasa_apod"} 1`)
+	require.Contains(t, body, `stellar_journal_worker_runs_total{result="failure",source="bing"} 2`)
+	require.Contains(t, body, `stellar_journal_worker_last_success_timestamp_seconds{source="nasa_apod"} 1.7041719e+09`)
+	require.NotContains(t, body, `stellar_journal_worker_last_success_timestamp_seconds{source="bing"}`)
+}
+
+func TestRateLimitMetrics(t *testing.T) {
+	limiter := &rateLimiter{}
+	m := metrics.New()
+	require.NoError(t, m.RegisterRateLimit(limiter))
+
+	require.NotContains(t, scrape(t, m), "stellar_journal_nasa_api_rate_limit")
+
+	*limiter = rateLimiter{Limit: 1000, Remaining: 998, UpdatedAt: time.Now()}
+	body := scrape(t, m)
+	require.Contains(t, body, "stellar_journal_nasa_api_rate_limit 1000")
+	require.Contains(t, body, "stellar_journal_nasa_api_rate_limit_remaining 998")
+}
+
+func TestDefaultCollectors(t *testing.T) {
+	body := scrape(t, metrics.New())
+	require.True(t, strings.Contains(body, "go_goroutines"), "go runtime metrics are missing")
+}
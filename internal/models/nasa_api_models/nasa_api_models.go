@@ -1,12 +1,15 @@
 package nasa_api_models
 
+import "stellar_journal/internal/lib/apod_date"
+
 type APODResp struct {
-	Copyright      string `json:"copyright"`
-	Date           string `json:"date"`
-	Explanation    string `json:"explanation"`
-	Hdurl          string `json:"hdurl"`
-	MediaType      string `json:"media_type"`
-	ServiceVersion string `json:"service_version"`
-	Title          string `json:"title"`
-	Url            string `json:"url"`
+	Copyright      string         `json:"copyright"`
+	Date           apod_date.Date `json:"date"`
+	Explanation    string         `json:"explanation"`
+	Hdurl          string         `json:"hdurl"`
+	MediaType      string         `json:"media_type"`
+	ServiceVersion string         `json:"service_version"`
+	ThumbnailUrl   string         `json:"thumbnail_url"`
+	Title          string         `json:"title"`
+	Url            string         `json:"url"`
 }
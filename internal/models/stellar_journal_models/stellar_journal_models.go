@@ -1,13 +1,186 @@
 package stellar_journal_models
 
+import (
+	"fmt"
+	"slices"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/video_embed"
+	"strings"
+	"time"
+)
+
+// APOD is a journal entry: the picture of the day of one source.
 type APOD struct {
-	Copyright      string `json:"copyright"`
-	Date           string `json:"date"`
-	Explanation    string `json:"explanation"`
-	Hdurl          string `json:"hdurl"`
-	MediaType      string `json:"media_type"`
-	ServiceVersion string `json:"service_version"`
-	Title          string `json:"title"`
-	Url            string `json:"url"`
-	Id             int    `json:"id"`
+	Source         string         `json:"source"`
+	Copyright      string         `json:"copyright"`
+	Date           apod_date.Date `json:"date"`
+	Explanation    string         `json:"explanation"`
+	Hdurl          string         `json:"hdurl"`
+	MediaType      string         `json:"media_type"`
+	ServiceVersion string         `json:"service_version"`
+	ThumbnailUrl   string         `json:"thumbnail_url"`
+	Title          string         `json:"title"`
+	Url            string         `json:"url"`
+	Id             int            `json:"id"`
+	// UpdatedAt is when the entry or its derivatives last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+	// Media tells clients how to render the APOD.
+	Media Media `json:"media"`
+	// Derivatives are resized copies of the archived image, ordered by format and width.
+	Derivatives []ImageDerivative `json:"derivatives,omitempty"`
+	// Srcset maps an image format to a value for the <img srcset> attribute.
+	Srcset map[string]string `json:"srcset,omitempty"`
+}
+
+// Sources of journal entries.
+const (
+	SourceNASAAPOD  = "nasa_apod"
+	SourceBing      = "bing"
+	SourceWikimedia = "wikimedia"
+	SourceESAHubble = "esa_hubble"
+)
+
+var sources = []string{SourceNASAAPOD, SourceBing, SourceWikimedia, SourceESAHubble}
+
+func IsSource(source string) bool {
+	return slices.Contains(sources, source)
+}
+
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+	MediaTypeOther = "other"
+)
+
+// Media describes what to render for an APOD: an <img> for images, an embed
+// (or a <video> when Provider is empty) for videos, and a link for anything
+// else, such as interactive pages.
+type Media struct {
+	Type         string `json:"type"`
+	URL          string `json:"url"`
+	HDURL        string `json:"hd_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	Provider     string `json:"provider,omitempty"`
+	VideoID      string `json:"video_id,omitempty"`
+}
+
+// NewMedia builds the Media of an APOD from the fields returned by the NASA API.
+// Protocol-relative URLs are turned into https ones.
+func NewMedia(mediaType, url, hdurl, thumbnailURL string) Media {
+	if strings.HasPrefix(url, "//") {
+		url = "https:" + url
+	}
+
+	m := Media{URL: url, ThumbnailURL: thumbnailURL}
+
+	switch mediaType {
+	case MediaTypeImage:
+		m.Type = MediaTypeImage
+		m.HDURL = hdurl
+	case MediaTypeVideo:
+		m.Type = MediaTypeVideo
+		if v, ok := video_embed.Parse(url); ok {
+			m.Provider, m.VideoID = v.Provider, v.ID
+			if m.ThumbnailURL == "" {
+				m.ThumbnailURL = v.ThumbnailURL()
+			}
+		}
+	default:
+		m.Type = MediaTypeOther
+	}
+
+	return m
+}
+
+type ImageDerivative struct {
+	Width  int    `json:"width"`
+	Format string `json:"format"`
+	URL    string `json:"url"`
+}
+
+// AddDerivative links a resized image to the APOD. Derivatives of a format must
+// be added in ascending width order.
+func (a *APOD) AddDerivative(width int, format string) {
+	url := fmt.Sprintf("/journal/%s/image?width=%d&format=%s", a.Date, width, format)
+	if a.Source != "" && a.Source != SourceNASAAPOD {
+		url += "&source=" + a.Source
+	}
+	a.Derivatives = append(a.Derivatives, ImageDerivative{Width: width, Format: format, URL: url})
+
+	if a.Srcset == nil {
+		a.Srcset = make(map[string]string)
+	}
+	if a.Srcset[format] != "" {
+		a.Srcset[format] += ", "
+	}
+	a.Srcset[format] += fmt.Sprintf("%s %dw", url, width)
+}
+
+// APODRevision is a prior version of the content of an APOD. UpdatedAt is when
+// the version was last modified and ReplacedAt when a correction replaced it.
+type APODRevision struct {
+	Id             int       `json:"id"`
+	Copyright      string    `json:"copyright"`
+	Explanation    string    `json:"explanation"`
+	Hdurl          string    `json:"hdurl"`
+	MediaType      string    `json:"media_type"`
+	ServiceVersion string    `json:"service_version"`
+	ThumbnailUrl   string    `json:"thumbnail_url"`
+	Title          string    `json:"title"`
+	Url            string    `json:"url"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ReplacedAt     time.Time `json:"replaced_at"`
+}
+
+type SearchResult struct {
+	APOD
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+const (
+	MediaVariantHD = "hd"
+	MediaVariantSD = "sd"
+)
+
+// APODMedia describes an image of an APOD archived in the blob store.
+type APODMedia struct {
+	Source      string         `json:"source"`
+	Date        apod_date.Date `json:"date"`
+	Variant     string         `json:"variant"`
+	SourceURL   string         `json:"source_url"`
+	StorageKey  string         `json:"storage_key"`
+	Checksum    string         `json:"checksum"`
+	ByteSize    int64          `json:"byte_size"`
+	ContentType string         `json:"content_type"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// APODDerivative describes a resized image of an APOD stored in the blob store.
+type APODDerivative struct {
+	Source      string         `json:"source"`
+	Date        apod_date.Date `json:"date"`
+	Width       int            `json:"width"`
+	Format      string         `json:"format"`
+	StorageKey  string         `json:"storage_key"`
+	Checksum    string         `json:"checksum"`
+	ByteSize    int64          `json:"byte_size"`
+	ContentType string         `json:"content_type"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// APIKey is a client's key to the API. Only its hash is stored; Prefix, the
+// start of the key, identifies it to people. A DailyQuota of 0 is unlimited.
+// Admin keys may also use the admin routes.
+type APIKey struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix"`
+	Admin         bool       `json:"admin"`
+	DailyQuota    int        `json:"daily_quota"`
+	RequestsToday int64      `json:"requests_today"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
 }
@@ -0,0 +1,104 @@
+package bing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"strings"
+	"time"
+)
+
+// archiveDays is the number of days the image archive endpoint serves at once.
+const archiveDays = 8
+
+// Provider serves the Bing homepage image of the day. Bing only keeps the
+// images of the last week available.
+type Provider struct {
+	host   string
+	market string
+	client *http.Client
+}
+
+// New creates a provider for the images of the given market, such as "en-US".
+func New(host, market string, timeout time.Duration) *Provider {
+	return &Provider{
+		host:   host,
+		market: market,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *Provider) Source() string {
+	return stellar_journal_models.SourceBing
+}
+
+type archive struct {
+	Images []struct {
+		StartDate string `json:"startdate"`
+		URL       string `json:"url"`
+		URLBase   string `json:"urlbase"`
+		Copyright string `json:"copyright"`
+		Title     string `json:"title"`
+	} `json:"images"`
+}
+
+func (p *Provider) GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	const op = "internal/providers/bing.GetByDate"
+
+	query := url.Values{
+		"format": {"js"},
+		"idx":    {"0"},
+		"n":      {fmt.Sprint(archiveDays)},
+		"mkt":    {p.market},
+	}
+
+	body, err := providers.Get(ctx, p.client, p.host+"/HPImageArchive.aspx?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var resp archive
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode response: %w", op, err)
+	}
+
+	var latest apod_date.Date
+	for _, image := range resp.Images {
+		t, err := time.Parse("20060102", image.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid start date %q: %w", op, image.StartDate, err)
+		}
+		imageDate := apod_date.FromTime(t)
+		if imageDate.After(latest) {
+			latest = imageDate
+		}
+		if !imageDate.Equal(date) {
+			continue
+		}
+
+		// The copyright reads "Description (© Author/Agency)".
+		explanation, copyright, _ := strings.Cut(image.Copyright, "(©")
+
+		return &stellar_journal_models.APOD{
+			Source:      stellar_journal_models.SourceBing,
+			Copyright:   strings.TrimSpace(strings.TrimSuffix(copyright, ")")),
+			Date:        date,
+			Explanation: strings.TrimSpace(explanation),
+			Hdurl:       p.host + image.URLBase + "_UHD.jpg",
+			MediaType:   stellar_journal_models.MediaTypeImage,
+			Title:       image.Title,
+			Url:         p.host + image.URL,
+		}, nil
+	}
+
+	if !date.Before(latest) {
+		return nil, fmt.Errorf("%s: %w", op, providers.ErrNotPublishedYet)
+	}
+
+	return nil, fmt.Errorf("%s: %w", op, providers.ErrNoPicture)
+}
@@ -0,0 +1,70 @@
+package bing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/providers/bing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const archive = `{"images": [
+	{
+		"startdate": "20240103",
+		"url": "/th?id=OHR.Comet_EN-US1_1920x1080.jpg&rf=LaDigue_1920x1080.jpg",
+		"urlbase": "/th?id=OHR.Comet_EN-US1",
+		"copyright": "A comet over the desert (© Jane Doe/Getty Images)",
+		"title": "Comet"
+	},
+	{
+		"startdate": "20240102",
+		"url": "/th?id=OHR.Aurora_EN-US2_1920x1080.jpg&rf=LaDigue_1920x1080.jpg",
+		"urlbase": "/th?id=OHR.Aurora_EN-US2",
+		"copyright": "Northern lights, Norway (© John Doe)",
+		"title": "Aurora"
+	}
+]}`
+
+func TestProvider_GetByDate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/HPImageArchive.aspx", r.URL.Path)
+		require.Equal(t, "en-GB", r.URL.Query().Get("mkt"))
+		require.Equal(t, providers.UserAgent, r.UserAgent())
+
+		_, _ = w.Write([]byte(archive))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := bing.New(srv.URL, "en-GB", time.Second)
+
+	t.Run("Success", func(t *testing.T) {
+		apod, err := p.GetByDate(context.Background(), apod_date.MustParse("2024-01-02"))
+		require.NoError(t, err)
+		require.Equal(t, &stellar_journal_models.APOD{
+			Source:      stellar_journal_models.SourceBing,
+			Copyright:   "John Doe",
+			Date:        apod_date.MustParse("2024-01-02"),
+			Explanation: "Northern lights, Norway",
+			Hdurl:       srv.URL + "/th?id=OHR.Aurora_EN-US2_UHD.jpg",
+			MediaType:   stellar_journal_models.MediaTypeImage,
+			Title:       "Aurora",
+			Url:         srv.URL + "/th?id=OHR.Aurora_EN-US2_1920x1080.jpg&rf=LaDigue_1920x1080.jpg",
+		}, apod)
+	})
+
+	t.Run("Not Published Yet", func(t *testing.T) {
+		_, err := p.GetByDate(context.Background(), apod_date.MustParse("2024-01-04"))
+		require.ErrorIs(t, err, providers.ErrNotPublishedYet)
+	})
+
+	t.Run("Out Of Archive", func(t *testing.T) {
+		_, err := p.GetByDate(context.Background(), apod_date.MustParse("2023-12-01"))
+		require.ErrorIs(t, err, providers.ErrNoPicture)
+	})
+}
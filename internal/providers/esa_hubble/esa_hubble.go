@@ -0,0 +1,149 @@
+package esa_hubble
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"strings"
+	"sync"
+	"time"
+)
+
+// feedTTL is how long a downloaded feed is reused for days it lists or that
+// are past. The feed changes once a week; days it may not list yet always
+// download it again, so that retries on the publication day see the picture.
+const feedTTL = time.Hour
+
+// Provider serves the ESA/Hubble Picture of the Week, published on Mondays.
+// Only the pictures still listed in the RSS feed are available.
+type Provider struct {
+	host   string
+	client *http.Client
+
+	mu        sync.Mutex
+	items     []item
+	fetchedAt time.Time
+}
+
+func New(host string, timeout time.Duration) *Provider {
+	return &Provider{
+		host:   host,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *Provider) Source() string {
+	return stellar_journal_models.SourceESAHubble
+}
+
+type item struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Enclosure   struct {
+		URL string `xml:"url,attr"`
+	} `xml:"enclosure"`
+}
+
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+func (p *Provider) GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	const op = "internal/providers/esa_hubble.GetByDate"
+
+	items, cached, err := p.feed(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apod, err := find(items, date)
+	if errors.Is(err, providers.ErrNotPublishedYet) && cached {
+		// The picture may have been published since the feed was downloaded.
+		if items, _, err = p.feed(ctx, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apod, err = find(items, date)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apod, nil
+}
+
+func find(items []item, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	var latest apod_date.Date
+	for _, it := range items {
+		published, err := parsePubDate(it.PubDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid publication date %q: %w", it.PubDate, err)
+		}
+		itemDate := apod_date.FromTime(published)
+		if itemDate.After(latest) {
+			latest = itemDate
+		}
+		if !itemDate.Equal(date) {
+			continue
+		}
+
+		return &stellar_journal_models.APOD{
+			Source:      stellar_journal_models.SourceESAHubble,
+			Copyright:   "ESA/Hubble",
+			Date:        date,
+			Explanation: strings.TrimSpace(html.UnescapeString(tags.ReplaceAllString(it.Description, ""))),
+			Hdurl:       strings.Replace(it.Enclosure.URL, "/screen/", "/large/", 1),
+			MediaType:   stellar_journal_models.MediaTypeImage,
+			Title:       it.Title,
+			Url:         it.Enclosure.URL,
+		}, nil
+	}
+
+	// The next picture is due a week after the latest one.
+	if !date.Before(latest.AddDays(7)) {
+		return nil, providers.ErrNotPublishedYet
+	}
+
+	return nil, providers.ErrNoPicture
+}
+
+// feed returns the items of the RSS feed, downloading it at most once per
+// feedTTL unless refresh is set, and reports whether they were cached.
+func (p *Provider) feed(ctx context.Context, refresh bool) ([]item, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !refresh && p.items != nil && time.Since(p.fetchedAt) < feedTTL {
+		return p.items, true, nil
+	}
+
+	body, err := providers.Get(ctx, p.client, p.host+"/rss/potw/")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var rss struct {
+		Items []item `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(body, &rss); err != nil {
+		return nil, false, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	p.items, p.fetchedAt = rss.Items, time.Now()
+	return p.items, false, nil
+}
+
+func parsePubDate(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC1123Z, s)
+	if err != nil {
+		return time.Parse(time.RFC1123, s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,90 @@
+package esa_hubble_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/providers/esa_hubble"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const feed = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0">
+	<channel>
+		<title>Picture of the Week</title>
+		<item>
+			<title>A Spiral in Pegasus</title>
+			<link>https://esahubble.org/images/potw2402a/</link>
+			<description>&lt;p&gt;This spiral galaxy lies in &lt;a href="#"&gt;Pegasus&lt;/a&gt;.&lt;/p&gt;</description>
+			<pubDate>Mon, 08 Jan 2024 06:00:00 +0100</pubDate>
+			<enclosure url="https://cdn.esahubble.org/archives/images/screen/potw2402a.jpg" length="1" type="image/jpeg"/>
+		</item>
+		<item>
+			<title>Stellar Nursery</title>
+			<link>https://esahubble.org/images/potw2401a/</link>
+			<description>A nursery.</description>
+			<pubDate>Mon, 01 Jan 2024 06:00:00 +0100</pubDate>
+			<enclosure url="https://cdn.esahubble.org/archives/images/screen/potw2401a.jpg" length="1" type="image/jpeg"/>
+		</item>
+	</channel>
+</rss>`
+
+const nextItem = `<channel>
+		<item>
+			<title>Galaxy Cluster</title>
+			<link>https://esahubble.org/images/potw2403a/</link>
+			<description>A cluster.</description>
+			<pubDate>Mon, 15 Jan 2024 06:00:00 +0100</pubDate>
+			<enclosure url="https://cdn.esahubble.org/archives/images/screen/potw2403a.jpg" length="1" type="image/jpeg"/>
+		</item>`
+
+func TestProvider_GetByDate(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/rss/potw/", r.URL.Path)
+
+		// The picture of 2024-01-15 is published after the second download.
+		if calls.Add(1) > 2 {
+			_, _ = w.Write([]byte(strings.Replace(feed, "<channel>", nextItem, 1)))
+			return
+		}
+		_, _ = w.Write([]byte(feed))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := esa_hubble.New(srv.URL, time.Second)
+
+	apod, err := p.GetByDate(context.Background(), apod_date.MustParse("2024-01-08"))
+	require.NoError(t, err)
+	require.Equal(t, &stellar_journal_models.APOD{
+		Source:      stellar_journal_models.SourceESAHubble,
+		Copyright:   "ESA/Hubble",
+		Date:        apod_date.MustParse("2024-01-08"),
+		Explanation: "This spiral galaxy lies in Pegasus.",
+		Hdurl:       "https://cdn.esahubble.org/archives/images/large/potw2402a.jpg",
+		MediaType:   stellar_journal_models.MediaTypeImage,
+		Title:       "A Spiral in Pegasus",
+		Url:         "https://cdn.esahubble.org/archives/images/screen/potw2402a.jpg",
+	}, apod)
+
+	_, err = p.GetByDate(context.Background(), apod_date.MustParse("2024-01-09"))
+	require.ErrorIs(t, err, providers.ErrNoPicture)
+
+	require.EqualValues(t, 1, calls.Load(), "the feed should be cached")
+
+	_, err = p.GetByDate(context.Background(), apod_date.MustParse("2024-01-15"))
+	require.ErrorIs(t, err, providers.ErrNotPublishedYet)
+	require.EqualValues(t, 2, calls.Load(), "days the feed may not list yet download it again")
+
+	apod, err = p.GetByDate(context.Background(), apod_date.MustParse("2024-01-15"))
+	require.NoError(t, err, "a retry on the publication day sees the new picture")
+	require.Equal(t, "Galaxy Cluster", apod.Title)
+}
@@ -0,0 +1,96 @@
+package nasa_apod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/nasa_api_models"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/stellar_api/nasa_api"
+)
+
+type APODAPI interface {
+	GetAPODByDate(ctx context.Context, date apod_date.Date) (*nasa_api_models.APODResp, error)
+	GetAPODRange(ctx context.Context, startDate, endDate apod_date.Date) ([]nasa_api_models.APODResp, error)
+}
+
+// Provider serves NASA's Astronomy Picture of the Day.
+type Provider struct {
+	api APODAPI
+}
+
+func New(api APODAPI) *Provider {
+	return &Provider{api: api}
+}
+
+func (p *Provider) Source() string {
+	return stellar_journal_models.SourceNASAAPOD
+}
+
+func (p *Provider) GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	const op = "internal/providers/nasa_apod.GetByDate"
+
+	resp, err := p.api.GetAPODByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translate(err))
+	}
+
+	// The API answers with the latest APOD for a date it has not reached yet
+	// in some time zones.
+	if !resp.Date.Equal(date) {
+		return nil, fmt.Errorf("%s: %w (got %s)", op, providers.ErrNotPublishedYet, resp.Date)
+	}
+
+	apod := toAPOD(resp)
+	return &apod, nil
+}
+
+// GetRange returns the APODs published between startDate and endDate
+// (inclusive) with a single request.
+func (p *Provider) GetRange(ctx context.Context, startDate, endDate apod_date.Date) ([]stellar_journal_models.APOD, error) {
+	const op = "internal/providers/nasa_apod.GetRange"
+
+	resps, err := p.api.GetAPODRange(ctx, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translate(err))
+	}
+
+	apods := make([]stellar_journal_models.APOD, 0, len(resps))
+	for i := range resps {
+		apods = append(apods, toAPOD(&resps[i]))
+	}
+
+	return apods, nil
+}
+
+func toAPOD(resp *nasa_api_models.APODResp) stellar_journal_models.APOD {
+	return stellar_journal_models.APOD{
+		Source:         stellar_journal_models.SourceNASAAPOD,
+		Copyright:      resp.Copyright,
+		Date:           resp.Date,
+		Explanation:    resp.Explanation,
+		Hdurl:          resp.Hdurl,
+		MediaType:      resp.MediaType,
+		ServiceVersion: resp.ServiceVersion,
+		ThumbnailUrl:   resp.ThumbnailUrl,
+		Title:          resp.Title,
+		Url:            resp.Url,
+	}
+}
+
+// translate wraps the errors of the NASA client into the provider errors while
+// keeping the original ones in the chain.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, nasa_api.ErrNotPublishedYet):
+		return fmt.Errorf("%w: %w", providers.ErrNotPublishedYet, err)
+	case errors.Is(err, nasa_api.ErrRateLimited):
+		return fmt.Errorf("%w: %w", providers.ErrRateLimited, err)
+	case errors.Is(err, nasa_api.ErrUnauthorized):
+		return fmt.Errorf("%w: %w", providers.ErrUnauthorized, err)
+	}
+
+	return err
+}
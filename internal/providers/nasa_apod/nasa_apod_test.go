@@ -0,0 +1,55 @@
+package nasa_apod_test
+
+import (
+	"context"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/nasa_api_models"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/providers/nasa_apod"
+	"stellar_journal/internal/stellar_api/nasa_api"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPI struct {
+	resp *nasa_api_models.APODResp
+	err  error
+}
+
+func (f fakeAPI) GetAPODByDate(context.Context, apod_date.Date) (*nasa_api_models.APODResp, error) {
+	return f.resp, f.err
+}
+
+func (f fakeAPI) GetAPODRange(context.Context, apod_date.Date, apod_date.Date) ([]nasa_api_models.APODResp, error) {
+	return []nasa_api_models.APODResp{*f.resp}, f.err
+}
+
+func TestProvider_GetByDate(t *testing.T) {
+	date := apod_date.MustParse("2024-01-02")
+
+	t.Run("Success", func(t *testing.T) {
+		p := nasa_apod.New(fakeAPI{resp: &nasa_api_models.APODResp{Date: date, Title: "Title", MediaType: "image"}})
+
+		apod, err := p.GetByDate(context.Background(), date)
+		require.NoError(t, err)
+		require.Equal(t, stellar_journal_models.SourceNASAAPOD, apod.Source)
+		require.Equal(t, "Title", apod.Title)
+	})
+
+	t.Run("Stale Date", func(t *testing.T) {
+		p := nasa_apod.New(fakeAPI{resp: &nasa_api_models.APODResp{Date: date.AddDays(-1)}})
+
+		_, err := p.GetByDate(context.Background(), date)
+		require.ErrorIs(t, err, providers.ErrNotPublishedYet)
+	})
+
+	t.Run("Translates Errors", func(t *testing.T) {
+		p := nasa_apod.New(fakeAPI{err: &nasa_api.StatusError{StatusCode: 403}})
+
+		_, err := p.GetByDate(context.Background(), date)
+		require.ErrorIs(t, err, providers.ErrUnauthorized)
+		require.ErrorIs(t, err, nasa_api.ErrUnauthorized)
+	})
+}
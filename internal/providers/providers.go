@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+)
+
+var (
+	// ErrNotPublishedYet means the source may still publish a picture for the
+	// date, so the fetch is worth retrying later.
+	ErrNotPublishedYet = errors.New("picture is not published yet")
+	// ErrNoPicture means the source has no picture for the date and never will.
+	ErrNoPicture    = errors.New("source has no picture for the date")
+	ErrRateLimited  = errors.New("source rate limit exceeded")
+	ErrUnauthorized = errors.New("source rejected the credentials")
+)
+
+// UserAgent identifies the journal to the sources, as some of them require.
+const UserAgent = "stellar_journal (+https://github.com/alexbro4u/stellar_journal)"
+
+// Provider fetches the picture of the day of one source. The returned APOD has
+// its Source set to Source().
+type Provider interface {
+	Source() string
+	GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error)
+}
+
+// Get requests url and returns the response body. Non-200 responses are
+// reported as errors matching ErrNoPicture (404), ErrUnauthorized (401, 403)
+// and ErrRateLimited (429).
+func Get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	const op = "internal/providers.Get"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create request: %w", op, err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to send request: %w", op, err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Printf("%s: failed to close response body: %v\n", op, err)
+		}
+	}(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", op, ErrNoPicture)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%s: %w", op, ErrUnauthorized)
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%s: %w", op, ErrRateLimited)
+	default:
+		return nil, fmt.Errorf("%s: unexpected status code: %d", op, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read response: %w", op, err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,93 @@
+package wikimedia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"strings"
+	"time"
+)
+
+// Provider serves the Wikimedia Commons picture of the day from the Wikipedia
+// featured content feed.
+type Provider struct {
+	host     string
+	language string
+	client   *http.Client
+}
+
+// New creates a provider reading the feed of the Wikipedia in the given
+// language, such as "en".
+func New(host, language string, timeout time.Duration) *Provider {
+	return &Provider{
+		host:     host,
+		language: language,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *Provider) Source() string {
+	return stellar_journal_models.SourceWikimedia
+}
+
+type featured struct {
+	Image *struct {
+		Title     string `json:"title"`
+		Thumbnail struct {
+			Source string `json:"source"`
+		} `json:"thumbnail"`
+		Image struct {
+			Source string `json:"source"`
+		} `json:"image"`
+		Artist struct {
+			Text string `json:"text"`
+		} `json:"artist"`
+		Description struct {
+			Text string `json:"text"`
+		} `json:"description"`
+	} `json:"image"`
+}
+
+func (p *Provider) GetByDate(ctx context.Context, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	const op = "internal/providers/wikimedia.GetByDate"
+
+	url := fmt.Sprintf("%s/feed/v1/wikipedia/%s/featured/%s", p.host, p.language, date.Time().Format("2006/01/02"))
+
+	body, err := providers.Get(ctx, p.client, url)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var resp featured
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode response: %w", op, err)
+	}
+	if resp.Image == nil {
+		return nil, fmt.Errorf("%s: %w", op, providers.ErrNoPicture)
+	}
+
+	image := resp.Image
+	apod := &stellar_journal_models.APOD{
+		Source:      stellar_journal_models.SourceWikimedia,
+		Copyright:   image.Artist.Text,
+		Date:        date,
+		Explanation: image.Description.Text,
+		Hdurl:       image.Image.Source,
+		MediaType:   stellar_journal_models.MediaTypeImage,
+		Title:       strings.TrimPrefix(image.Title, "File:"),
+		Url:         image.Thumbnail.Source,
+	}
+
+	switch strings.ToLower(path.Ext(image.Image.Source)) {
+	case ".webm", ".ogv":
+		apod.MediaType = stellar_journal_models.MediaTypeVideo
+		apod.Url, apod.Hdurl, apod.ThumbnailUrl = image.Image.Source, "", image.Thumbnail.Source
+	}
+
+	return apod, nil
+}
@@ -0,0 +1,104 @@
+package wikimedia_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/providers"
+	"stellar_journal/internal/providers/wikimedia"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_GetByDate(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		apod   *stellar_journal_models.APOD
+		err    error
+	}{
+		{
+			name:   "Image",
+			status: http.StatusOK,
+			body: `{"image": {
+				"title": "File:Andromeda Galaxy.jpg",
+				"thumbnail": {"source": "https://upload.example/thumb/640px-Andromeda_Galaxy.jpg"},
+				"image": {"source": "https://upload.example/Andromeda_Galaxy.jpg"},
+				"artist": {"text": "Jane Doe"},
+				"description": {"text": "The Andromeda Galaxy."}
+			}}`,
+			apod: &stellar_journal_models.APOD{
+				Source:      stellar_journal_models.SourceWikimedia,
+				Copyright:   "Jane Doe",
+				Date:        apod_date.MustParse("2024-01-02"),
+				Explanation: "The Andromeda Galaxy.",
+				Hdurl:       "https://upload.example/Andromeda_Galaxy.jpg",
+				MediaType:   stellar_journal_models.MediaTypeImage,
+				Title:       "Andromeda Galaxy.jpg",
+				Url:         "https://upload.example/thumb/640px-Andromeda_Galaxy.jpg",
+			},
+		},
+		{
+			name:   "Video",
+			status: http.StatusOK,
+			body: `{"image": {
+				"title": "File:Eclipse.webm",
+				"thumbnail": {"source": "https://upload.example/thumb/Eclipse.jpg"},
+				"image": {"source": "https://upload.example/Eclipse.webm"}
+			}}`,
+			apod: &stellar_journal_models.APOD{
+				Source:       stellar_journal_models.SourceWikimedia,
+				Date:         apod_date.MustParse("2024-01-02"),
+				MediaType:    stellar_journal_models.MediaTypeVideo,
+				ThumbnailUrl: "https://upload.example/thumb/Eclipse.jpg",
+				Title:        "Eclipse.webm",
+				Url:          "https://upload.example/Eclipse.webm",
+			},
+		},
+		{
+			name:   "No Image",
+			status: http.StatusOK,
+			body:   `{"tfa": {}}`,
+			err:    providers.ErrNoPicture,
+		},
+		{
+			name:   "Not Found",
+			status: http.StatusNotFound,
+			err:    providers.ErrNoPicture,
+		},
+		{
+			name:   "Rate Limited",
+			status: http.StatusTooManyRequests,
+			err:    providers.ErrRateLimited,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/feed/v1/wikipedia/de/featured/2024/01/02", r.URL.Path)
+
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			apod, err := wikimedia.New(srv.URL, "de", time.Second).GetByDate(context.Background(), apod_date.MustParse("2024-01-02"))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.apod, apod)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/rate_limit"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// Limiter keeps the buckets in the process, so every replica enforces the
+// limit on its own.
+type Limiter struct {
+	policy rate_limit.Policy
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(policy rate_limit.Policy, clk clock.Clock) *Limiter {
+	return &Limiter{
+		policy:    policy,
+		clock:     clk,
+		buckets:   make(map[string]*bucket),
+		lastSweep: clk.Now(),
+	}
+}
+
+func (l *Limiter) Allow(_ context.Context, key string) (rate_limit.Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.policy.Limit), at: now}
+		l.buckets[key] = b
+	}
+
+	var allowed bool
+	b.tokens, allowed = l.policy.Take(b.tokens, now.Sub(b.at))
+	b.at = now
+
+	return l.policy.Decision(allowed, b.tokens), nil
+}
+
+// Len returns the number of buckets kept.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// sweep drops, once per window, the buckets idle for a whole window: they are
+// full again, so a new bucket is the same.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.at) >= l.policy.Window {
+			delete(l.buckets, key)
+		}
+	}
+}
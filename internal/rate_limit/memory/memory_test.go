@@ -0,0 +1,58 @@
+package memory_test
+
+import (
+	"context"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"stellar_journal/internal/rate_limit"
+	"stellar_journal/internal/rate_limit/memory"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	clk := fakeclock.New(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC))
+	limiter := memory.NewLimiter(rate_limit.Policy{Limit: 3, Window: 3 * time.Second}, clk)
+
+	for i := 2; i >= 0; i-- {
+		d, err := limiter.Allow(ctx, "ip:192.0.2.1")
+		require.NoError(t, err)
+		require.True(t, d.Allowed)
+		require.Equal(t, i, d.Remaining)
+	}
+
+	d, err := limiter.Allow(ctx, "ip:192.0.2.1")
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, time.Second, d.RetryAfter)
+
+	d, err = limiter.Allow(ctx, "ip:192.0.2.2")
+	require.NoError(t, err)
+	require.True(t, d.Allowed, "clients have their own buckets")
+
+	clk.Advance(time.Second)
+	d, err = limiter.Allow(ctx, "ip:192.0.2.1")
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	require.Equal(t, 0, d.Remaining)
+}
+
+func TestLimiterDropsIdleBuckets(t *testing.T) {
+	ctx := context.Background()
+	clk := fakeclock.New(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC))
+	limiter := memory.NewLimiter(rate_limit.Policy{Limit: 3, Window: time.Minute}, clk)
+
+	_, err := limiter.Allow(ctx, "ip:192.0.2.1")
+	require.NoError(t, err)
+	clk.Advance(30 * time.Second)
+	_, err = limiter.Allow(ctx, "ip:192.0.2.2")
+	require.NoError(t, err)
+	require.Equal(t, 2, limiter.Len())
+
+	clk.Advance(30 * time.Second)
+	_, err = limiter.Allow(ctx, "ip:192.0.2.3")
+	require.NoError(t, err)
+	require.Equal(t, 2, limiter.Len(), "the bucket idle for a window is dropped")
+}
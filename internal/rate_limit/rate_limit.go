@@ -0,0 +1,73 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Policy is a token bucket holding Limit tokens that refills completely over
+// Window: a client may burst Limit requests and then make Limit requests per
+// Window.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Decision is the outcome of taking a token for a request.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, when the request was denied.
+	RetryAfter time.Duration
+}
+
+// Limiter takes a token from the bucket of key.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Decision, error)
+}
+
+// rate is the number of tokens added per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Take refills a bucket that had tokens elapsed ago and takes a token from it
+// if there is one. It returns the tokens left.
+func (p Policy) Take(tokens float64, elapsed time.Duration) (float64, bool) {
+	tokens = math.Min(float64(p.Limit), tokens+math.Max(elapsed.Seconds(), 0)*p.rate())
+	if tokens < 1 {
+		return tokens, false
+	}
+
+	return tokens - 1, true
+}
+
+// Decision describes a bucket left with tokens after a request.
+func (p Policy) Decision(allowed bool, tokens float64) Decision {
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     p.seconds(float64(p.Limit) - tokens),
+	}
+	if !allowed {
+		d.RetryAfter = p.seconds(1 - tokens)
+	}
+
+	return d
+}
+
+// seconds returns how long refilling the tokens takes.
+func (p Policy) seconds(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / p.rate() * float64(time.Second)))
+}
+
+// String formats the policy for the RateLimit-Policy header.
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Window.Seconds()))
+}
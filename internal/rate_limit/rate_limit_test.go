@@ -0,0 +1,59 @@
+package rate_limit_test
+
+import (
+	"stellar_journal/internal/rate_limit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var policy = rate_limit.Policy{Limit: 60, Window: time.Minute}
+
+func TestPolicyTake(t *testing.T) {
+	cases := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		left    float64
+		allowed bool
+	}{
+		{name: "Full", tokens: 60, left: 59, allowed: true},
+		{name: "Refills", tokens: 0, elapsed: 2 * time.Second, left: 1, allowed: true},
+		{name: "Caps At Limit", tokens: 10, elapsed: time.Hour, left: 59, allowed: true},
+		{name: "Empty", tokens: 0.5, left: 0.5, allowed: false},
+		{name: "Clock Went Back", tokens: 0, elapsed: -time.Second, left: 0, allowed: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			left, allowed := policy.Take(tc.tokens, tc.elapsed)
+			require.Equal(t, tc.allowed, allowed)
+			require.InDelta(t, tc.left, left, 1e-9)
+		})
+	}
+}
+
+func TestPolicyDecision(t *testing.T) {
+	require.Equal(t, rate_limit.Decision{
+		Allowed:   true,
+		Limit:     60,
+		Remaining: 58,
+		Reset:     1500 * time.Millisecond,
+	}, policy.Decision(true, 58.5))
+
+	require.Equal(t, rate_limit.Decision{
+		Limit:      60,
+		Remaining:  0,
+		Reset:      59750 * time.Millisecond,
+		RetryAfter: 750 * time.Millisecond,
+	}, policy.Decision(false, 0.25))
+}
+
+func TestPolicyString(t *testing.T) {
+	require.Equal(t, "60;w=60", policy.String())
+}
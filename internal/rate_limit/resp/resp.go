@@ -0,0 +1,83 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"stellar_journal/internal/rate_limit"
+	"strconv"
+)
+
+const keyPrefix = "stellar_journal:rate_limit:"
+
+// script takes a token from the bucket in the hash KEYS[1], refilling it at
+// ARGV[1] tokens per second up to ARGV[2]. It runs atomically on the server and
+// uses the server's clock, so replicas with skewed clocks share one bucket. It
+// returns whether a token was taken and the tokens left as a string, since Lua
+// numbers are truncated to integers in replies.
+const script = `
+local rate = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or limit
+local at = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - at) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((limit - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// Doer sends a command to a RESP server; *resp.Store of cache_store is one.
+type Doer interface {
+	Do(ctx context.Context, args ...any) (any, error)
+}
+
+// Limiter keeps the buckets in a RESP server such as Redis or Valkey, so that
+// every replica using the server enforces one limit.
+type Limiter struct {
+	server Doer
+	policy rate_limit.Policy
+}
+
+func NewLimiter(server Doer, policy rate_limit.Policy) *Limiter {
+	return &Limiter{server: server, policy: policy}
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string) (rate_limit.Decision, error) {
+	const op = "internal/rate_limit/resp.Allow"
+
+	rate := float64(l.policy.Limit) / l.policy.Window.Seconds()
+	reply, err := l.server.Do(ctx, "EVAL", script, "1", keyPrefix+key,
+		strconv.FormatFloat(rate, 'g', -1, 64), strconv.Itoa(l.policy.Limit))
+	if err != nil {
+		return rate_limit.Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items, ok := reply.([]any)
+	if !ok || len(items) != 2 {
+		return rate_limit.Decision{}, fmt.Errorf("%s: unexpected reply %v", op, reply)
+	}
+	allowed, ok := items[0].(int64)
+	if !ok {
+		return rate_limit.Decision{}, fmt.Errorf("%s: unexpected reply %v", op, reply)
+	}
+	raw, ok := items[1].([]byte)
+	if !ok {
+		return rate_limit.Decision{}, fmt.Errorf("%s: unexpected reply %v", op, reply)
+	}
+	tokens, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return rate_limit.Decision{}, fmt.Errorf("%s: invalid tokens %q: %w", op, raw, err)
+	}
+
+	return l.policy.Decision(allowed == 1, tokens), nil
+}
@@ -0,0 +1,67 @@
+package resp_test
+
+import (
+	"context"
+	"errors"
+	"stellar_journal/internal/rate_limit"
+	"stellar_journal/internal/rate_limit/resp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServer struct {
+	args  []any
+	reply any
+	err   error
+}
+
+func (s *fakeServer) Do(_ context.Context, args ...any) (any, error) {
+	s.args = args
+	return s.reply, s.err
+}
+
+var policy = rate_limit.Policy{Limit: 60, Window: time.Minute}
+
+func TestLimiterAllow(t *testing.T) {
+	server := &fakeServer{reply: []any{int64(1), []byte("58.5")}}
+
+	d, err := resp.NewLimiter(server, policy).Allow(context.Background(), "ip:192.0.2.1")
+	require.NoError(t, err)
+	require.Equal(t, policy.Decision(true, 58.5), d)
+
+	require.Equal(t, "EVAL", server.args[0])
+	require.Equal(t, []any{"1", "stellar_journal:rate_limit:ip:192.0.2.1", "1", "60"}, server.args[2:])
+}
+
+func TestLimiterDeny(t *testing.T) {
+	server := &fakeServer{reply: []any{int64(0), []byte("0.25")}}
+
+	d, err := resp.NewLimiter(server, policy).Allow(context.Background(), "ip:192.0.2.1")
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 750*time.Millisecond, d.RetryAfter)
+}
+
+func TestLimiterErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		server *fakeServer
+	}{
+		{name: "Server Error", server: &fakeServer{err: errors.New("connection refused")}},
+		{name: "Unexpected Reply", server: &fakeServer{reply: []byte("OK")}},
+		{name: "Invalid Tokens", server: &fakeServer{reply: []any{int64(1), []byte("many")}}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := resp.NewLimiter(tc.server, policy).Allow(context.Background(), "ip:192.0.2.1")
+			require.Error(t, err)
+		})
+	}
+}
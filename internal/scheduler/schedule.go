@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+// Schedule returns the next activation time strictly after t.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type daily struct {
+	hour, minute int
+	loc          *time.Location
+}
+
+// Daily activates every day at hour:minute in loc.
+func Daily(hour, minute int, loc *time.Location) Schedule {
+	return daily{hour: hour, minute: minute, loc: loc}
+}
+
+// ParseDaily parses a "HH:MM" time of day.
+func ParseDaily(at string, loc *time.Location) (Schedule, error) {
+	const op = "internal/scheduler.ParseDaily"
+
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid time of day %q, expected HH:MM", op, at)
+	}
+
+	return Daily(t.Hour(), t.Minute(), loc), nil
+}
+
+func (d daily) Next(t time.Time) time.Time {
+	local := t.In(d.loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), d.hour, d.minute, 0, 0, d.loc)
+	if !next.After(t) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, d.hour, d.minute, 0, 0, d.loc)
+	}
+
+	return next
+}
+
+type cronSchedule struct {
+	schedule cron.Schedule
+	loc      *time.Location
+}
+
+// ParseCron parses a standard five-field cron expression evaluated in loc.
+func ParseCron(expr string, loc *time.Location) (Schedule, error) {
+	const op = "internal/scheduler.ParseCron"
+
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid cron expression %q: %w", op, expr, err)
+	}
+
+	return cronSchedule{schedule: schedule, loc: loc}, nil
+}
+
+func (c cronSchedule) Next(t time.Time) time.Time {
+	return c.schedule.Next(t.In(c.loc))
+}
+
+// ParseSchedule builds the schedule described by the configuration: the cron
+// expression when it is set, the daily time otherwise. Both are evaluated in
+// the named time zone.
+func ParseSchedule(cronExpr, dailyAt, timezone string) (Schedule, error) {
+	const op = "internal/scheduler.ParseSchedule"
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid timezone %q: %w", op, timezone, err)
+	}
+
+	if cronExpr != "" {
+		return ParseCron(cronExpr, loc)
+	}
+
+	return ParseDaily(dailyAt, loc)
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/logger/sl"
+	"sync/atomic"
+	"time"
+)
+
+// Job is the work run on every activation. now is the clock time of the call.
+type Job func(ctx context.Context, now time.Time) error
+
+type permanentError struct {
+	err error
+}
+
+// Permanent marks a job error that retrying cannot fix. The job is run again
+// at the next activation only.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+type Scheduler struct {
+	schedule   Schedule
+	retry      backoff.Policy
+	runOnStart bool
+	clock      clock.Clock
+	logger     *slog.Logger
+	// next is the UnixNano time of the next run, 0 while the job runs.
+	next atomic.Int64
+}
+
+// New creates a scheduler that runs a job on every activation of schedule and
+// retries it according to the retry policy when it fails. When runOnStart is
+// set the job also runs as soon as the scheduler starts.
+func New(schedule Schedule, retry backoff.Policy, runOnStart bool, clk clock.Clock, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		schedule:   schedule,
+		retry:      retry,
+		runOnStart: runOnStart,
+		clock:      clk,
+		logger:     logger,
+	}
+}
+
+// Run runs job according to the schedule until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, job Job) {
+	if s.runOnStart {
+		s.runWithRetries(ctx, job)
+	}
+
+	for ctx.Err() == nil {
+		next := s.schedule.Next(s.clock.Now())
+		s.logger.Debug("next scheduled run", slog.Time("at", next))
+
+		if !s.sleepUntil(ctx, next) {
+			return
+		}
+
+		s.runWithRetries(ctx, job)
+	}
+}
+
+// Next returns when the job runs next, a retry included. It is the zero time
+// while the job runs and before Run started.
+func (s *Scheduler) Next() time.Time {
+	nanos := s.next.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+// runWithRetries calls job until it succeeds, the attempts are exhausted or a
+// retry would not happen before the next activation.
+func (s *Scheduler) runWithRetries(ctx context.Context, job Job) {
+	for attempt := 1; ; attempt++ {
+		s.next.Store(0)
+		now := s.clock.Now()
+
+		err := job(ctx, now)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			s.logger.Error("scheduled job failed, waiting for next run", slog.Int("attempt", attempt), sl.Err(err))
+			return
+		}
+
+		if s.retry.MaxAttempts > 0 && attempt >= s.retry.MaxAttempts {
+			s.logger.Error("scheduled job failed, giving up", slog.Int("attempt", attempt), sl.Err(err))
+			return
+		}
+
+		delay := s.retry.Delay(attempt)
+		if !now.Add(delay).Before(s.schedule.Next(now)) {
+			s.logger.Error("scheduled job failed, waiting for next run", slog.Int("attempt", attempt), sl.Err(err))
+			return
+		}
+
+		s.logger.Warn(
+			"scheduled job failed, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			sl.Err(err),
+		)
+
+		if !s.sleepUntil(ctx, now.Add(delay)) {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) sleepUntil(ctx context.Context, t time.Time) bool {
+	s.next.Store(t.UnixNano())
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.clock.After(t.Sub(s.clock.Now())):
+		return true
+	}
+}
@@ -0,0 +1,157 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/lib/clock/fakeclock"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/scheduler"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var newYork = mustLoadLocation("America/New_York")
+
+func TestDaily_Next(t *testing.T) {
+	daily := scheduler.Daily(0, 5, newYork)
+
+	cases := []struct {
+		name string
+		now  time.Time
+		next time.Time
+	}{
+		{
+			name: "Later Today",
+			now:  time.Date(2024, time.January, 2, 0, 1, 0, 0, newYork),
+			next: time.Date(2024, time.January, 2, 0, 5, 0, 0, newYork),
+		},
+		{
+			name: "At Activation",
+			now:  time.Date(2024, time.January, 2, 0, 5, 0, 0, newYork),
+			next: time.Date(2024, time.January, 3, 0, 5, 0, 0, newYork),
+		},
+		{
+			name: "Other Time Zone",
+			now:  time.Date(2024, time.January, 2, 4, 0, 0, 0, time.UTC),
+			next: time.Date(2024, time.January, 2, 0, 5, 0, 0, newYork),
+		},
+		{
+			name: "Across DST Change",
+			now:  time.Date(2024, time.March, 10, 0, 5, 0, 0, newYork),
+			next: time.Date(2024, time.March, 11, 0, 5, 0, 0, newYork),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.True(t, tc.next.Equal(daily.Next(tc.now)), "got %s", daily.Next(tc.now))
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 0, 1, 0, 0, newYork)
+
+	daily, err := scheduler.ParseSchedule("", "00:05", "America/New_York")
+	require.NoError(t, err)
+	require.True(t, now.Add(4*time.Minute).Equal(daily.Next(now)))
+
+	cron, err := scheduler.ParseSchedule("*/15 * * * *", "00:05", "America/New_York")
+	require.NoError(t, err)
+	require.True(t, now.Add(14*time.Minute).Equal(cron.Next(now)))
+
+	_, err = scheduler.ParseSchedule("", "25:00", "America/New_York")
+	require.Error(t, err)
+
+	_, err = scheduler.ParseSchedule("every day", "", "America/New_York")
+	require.Error(t, err)
+
+	_, err = scheduler.ParseSchedule("", "00:05", "Mars/Olympus_Mons")
+	require.Error(t, err)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	start := time.Date(2024, time.January, 2, 0, 0, 0, 0, newYork)
+	hourly, err := scheduler.ParseCron("0 * * * *", newYork)
+	require.NoError(t, err)
+
+	t.Run("RetriesUntilNextActivation", func(t *testing.T) {
+		clk := fakeclock.New(start)
+		retry := backoff.Policy{Initial: 20 * time.Minute, Multiplier: 2}
+		s := scheduler.New(hourly, retry, true, clk, slogdiscard.NewDiscardLogger())
+
+		var calls []time.Time
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx, func(_ context.Context, now time.Time) error {
+			calls = append(calls, now)
+			return errors.New("error")
+		})
+
+		// 00:00 fails, retried at 00:20; the next retry (00:60) is not before
+		// the next activation, so the scheduler waits for 01:00.
+		clk.BlockUntil(1)
+		require.Equal(t, start.Add(20*time.Minute), clk.Next())
+		require.True(t, start.Add(20*time.Minute).Equal(s.Next()), "got %s", s.Next())
+		clk.Advance(20 * time.Minute)
+		clk.BlockUntil(1)
+		require.Equal(t, start.Add(time.Hour), clk.Next())
+		require.True(t, start.Add(time.Hour).Equal(s.Next()), "got %s", s.Next())
+		require.Equal(t, []time.Time{start, start.Add(20 * time.Minute)}, calls)
+	})
+
+	t.Run("DoesNotRetryPermanentErrors", func(t *testing.T) {
+		clk := fakeclock.New(start)
+		retry := backoff.Policy{Initial: time.Minute}
+		s := scheduler.New(hourly, retry, true, clk, slogdiscard.NewDiscardLogger())
+
+		calls := 0
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx, func(context.Context, time.Time) error {
+			calls++
+			return scheduler.Permanent(errors.New("error"))
+		})
+
+		clk.BlockUntil(1)
+		require.Equal(t, start.Add(time.Hour), clk.Next())
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("StopsOnCancel", func(t *testing.T) {
+		clk := fakeclock.New(start)
+		s := scheduler.New(hourly, backoff.Policy{}, false, clk, slogdiscard.NewDiscardLogger())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			s.Run(ctx, func(context.Context, time.Time) error { return nil })
+			close(stopped)
+		}()
+
+		clk.BlockUntil(1)
+		cancel()
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler did not stop after cancellation")
+		}
+	})
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
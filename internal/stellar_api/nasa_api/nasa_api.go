@@ -2,79 +2,299 @@ package nasa_api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/backoff"
 	"stellar_journal/internal/models/nasa_api_models"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrRateLimited     = errors.New("nasa api rate limit exceeded")
+	ErrUnauthorized    = errors.New("nasa api rejected the api key")
+	ErrNotPublishedYet = errors.New("apod is not published yet")
+	ErrUnavailable     = errors.New("nasa api is unavailable")
 )
 
+// StatusError is returned for every non-200 response. It matches the
+// package errors with errors.Is according to its status code.
+type StatusError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotPublishedYet:
+		// The API answers 404 for today's date until the picture is published
+		// and 400 for dates after the latest published one.
+		return e.StatusCode == http.StatusNotFound ||
+			e.StatusCode == http.StatusBadRequest && strings.HasPrefix(e.Message, "Date must be between")
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// RateLimit is the request quota reported by the X-RateLimit-* headers of the
+// latest response. UpdatedAt is zero until a response carried them.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var tracer = otel.Tracer("stellar_journal/internal/stellar_api/nasa_api")
+
 type NasaApi struct {
 	Host  string `json:"host"`
 	Token string `json:"token"`
+
+	client *http.Client
+	retry  backoff.Policy
+
+	mu        sync.Mutex
+	rateLimit RateLimit
 }
 
-func NewNasaApiConnect(host, token string) *NasaApi {
+// NewNasaApiConnect creates a client whose requests time out after timeout.
+// Rate-limited (429), failed (5xx) and unsent requests are retried according to
+// retry; a Retry-After header extends the delay up to retry.Max.
+func NewNasaApiConnect(host, token string, timeout time.Duration, retry backoff.Policy) *NasaApi {
 	return &NasaApi{
-		Host:  host,
-		Token: token,
+		Host:   host,
+		Token:  token,
+		client: &http.Client{Timeout: timeout},
+		retry:  retry,
 	}
 }
 
-func (a *NasaApi) createClient() *http.Client {
-	return &http.Client{
-		Timeout: 30 * time.Second,
-	}
+// RateLimit returns the quota reported by the latest response.
+func (a *NasaApi) RateLimit() RateLimit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.rateLimit
 }
 
-func (a *NasaApi) createRequest(method, url string, body []byte) (*http.Request, error) {
+func (a *NasaApi) createRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
 	const op = "internal/stellar_api/nasa_api.createRequest"
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to create request: %w", op, err)
+		return nil, fmt.Errorf("%s: failed to create request: %w", op, redactURL(err))
 	}
 	req.Header.Set("Content-Type", "application/json")
 	return req, nil
 }
 
-func (a *NasaApi) doRequest(req *http.Request, target interface{}) error {
+// get requests url and decodes the response into target, retrying transient
+// failures.
+func (a *NasaApi) get(ctx context.Context, url string, target interface{}) error {
+	const op = "internal/stellar_api/nasa_api.get"
+
+	for attempt := 1; ; attempt++ {
+		req, err := a.createRequest(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("%s: failed to create request: %w", op, err)
+		}
+
+		err = a.doRequest(req, target, attempt)
+		if err == nil {
+			return nil
+		}
+
+		if !retryable(ctx, err) || a.retry.MaxAttempts > 0 && attempt >= a.retry.MaxAttempts {
+			return err
+		}
+
+		delay := a.retry.Delay(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+			if a.retry.Max > 0 {
+				delay = min(delay, a.retry.Max)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w (last error: %v)", op, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// doRequest sends req in a client span and passes the trace on in the
+// traceparent header. The query is not recorded since it carries the API key,
+// and it is dropped from transport errors for the same reason.
+func (a *NasaApi) doRequest(req *http.Request, target interface{}, attempt int) (err error) {
 	const op = "internal/stellar_api/nasa_api.doRequest"
 
-	client := a.createClient()
-	resp, err := client.Do(req)
+	ctx, span := tracer.Start(req.Context(), req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+			semconv.HTTPRequestResendCount(attempt-1),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := a.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("%s: failed to send request: %w", op, err)
+		return fmt.Errorf("%s: failed to send request: %w", op, redactURL(err))
 	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			fmt.Printf("%s: failed to close response body: %w", op, err)
+			fmt.Printf("%s: failed to close response body: %v\n", op, err)
 		}
 	}(resp.Body)
 
+	a.updateRateLimit(resp.Header)
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s: unexpected status code: %d", op, resp.StatusCode)
+		return fmt.Errorf("%s: %w", op, newStatusError(resp))
 	}
 
 	return json.NewDecoder(resp.Body).Decode(target)
 }
 
-func (a *NasaApi) GetAPOD() (*nasa_api_models.APODResp, error) {
-	const op = "internal/stellar_api/nasa_api.GetAPOD"
+// redactURL drops the query, which carries the API key, from the URL a
+// *url.Error names, so that the key stays out of logs, traces and worker stats.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		u, parseErr := url.Parse(urlErr.URL)
+		if parseErr != nil {
+			urlErr.URL = "<invalid url>"
+		} else {
+			u.RawQuery = ""
+			urlErr.URL = u.String()
+		}
+	}
 
-	url := fmt.Sprintf("%s/planetary/apod?api_key=%s", a.Host, a.Token)
-	req, err := a.createRequest("GET", url, nil)
+	return err
+}
+
+func (a *NasaApi) updateRateLimit(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to create request: %w", op, err)
+		return
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rateLimit = RateLimit{Limit: limit, Remaining: remaining, UpdatedAt: time.Now()}
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	statusErr := &StatusError{StatusCode: resp.StatusCode}
+
+	// The APOD service reports {"code": ..., "msg": ...}, the api.data.gov
+	// gateway in front of it {"error": {"code": ..., "message": ...}}.
+	var body struct {
+		Msg   string `json:"msg"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil {
+		statusErr.Message = body.Msg
+		if statusErr.Message == "" {
+			statusErr.Message = body.Error.Message
+		}
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(resp.Header.Get("Retry-After")); err == nil {
+		statusErr.RetryAfter = time.Until(at)
+	}
+
+	return statusErr
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var urlErr *url.Error
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable) || errors.As(err, &urlErr)
+}
+
+func (a *NasaApi) GetAPODByDate(ctx context.Context, date apod_date.Date) (*nasa_api_models.APODResp, error) {
+	const op = "internal/stellar_api/nasa_api.GetAPODByDate"
+
+	url := fmt.Sprintf("%s/planetary/apod?api_key=%s&thumbs=true&date=%s", a.Host, a.Token, date)
+
 	var apodResp nasa_api_models.APODResp
-	err = a.doRequest(req, &apodResp)
+	err := a.get(ctx, url, &apodResp)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to do request: %w", op, err)
 	}
 
 	return &apodResp, nil
 }
+
+func (a *NasaApi) GetAPODRange(ctx context.Context, startDate, endDate apod_date.Date) ([]nasa_api_models.APODResp, error) {
+	const op = "internal/stellar_api/nasa_api.GetAPODRange"
+
+	url := fmt.Sprintf("%s/planetary/apod?api_key=%s&thumbs=true&start_date=%s&end_date=%s", a.Host, a.Token, startDate, endDate)
+
+	var apodResp []nasa_api_models.APODResp
+	err := a.get(ctx, url, &apodResp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to do request: %w", op, err)
+	}
+
+	return apodResp, nil
+}
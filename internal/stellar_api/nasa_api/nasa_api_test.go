@@ -0,0 +1,216 @@
+package nasa_api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/stellar_api/nasa_api"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var retry = backoff.Policy{Initial: time.Millisecond, Max: 10 * time.Millisecond, MaxAttempts: 3}
+
+type response struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// serve replies with the given responses in order, repeating the last one.
+func serve(t *testing.T, responses ...response) (*nasa_api.NasaApi, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/planetary/apod", r.URL.Path)
+		require.Equal(t, "token", r.URL.Query().Get("api_key"))
+
+		resp := responses[min(int(calls.Add(1)), len(responses))-1]
+		for k, v := range resp.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.status)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return nasa_api.NewNasaApiConnect(srv.URL, "token", time.Second, retry), &calls
+}
+
+func TestNasaApi_GetAPODByDate(t *testing.T) {
+	ok := response{
+		status: http.StatusOK,
+		header: map[string]string{"X-RateLimit-Limit": "1000", "X-RateLimit-Remaining": "998"},
+		body:   `{"date": "2024-01-02", "title": "Title", "media_type": "image"}`,
+	}
+
+	cases := []struct {
+		name      string
+		responses []response
+		calls     int
+		err       error
+	}{
+		{
+			name:      "Success",
+			responses: []response{ok},
+			calls:     1,
+		},
+		{
+			name: "Retries Rate Limited",
+			responses: []response{
+				{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}},
+				ok,
+			},
+			calls: 2,
+		},
+		{
+			name:      "Retries Server Errors",
+			responses: []response{{status: http.StatusBadGateway}, {status: http.StatusServiceUnavailable}, ok},
+			calls:     3,
+		},
+		{
+			name:      "Gives Up",
+			responses: []response{{status: http.StatusServiceUnavailable}},
+			calls:     retry.MaxAttempts,
+			err:       nasa_api.ErrUnavailable,
+		},
+		{
+			name: "Rate Limited",
+			responses: []response{{
+				status: http.StatusTooManyRequests,
+				body:   `{"error": {"code": "OVER_RATE_LIMIT", "message": "You have exceeded your rate limit."}}`,
+			}},
+			calls: retry.MaxAttempts,
+			err:   nasa_api.ErrRateLimited,
+		},
+		{
+			name:      "Unauthorized",
+			responses: []response{{status: http.StatusForbidden, body: `{"error": {"code": "API_KEY_INVALID"}}`}},
+			calls:     1,
+			err:       nasa_api.ErrUnauthorized,
+		},
+		{
+			name:      "Not Published Yet",
+			responses: []response{{status: http.StatusNotFound, body: `{"code": 404, "msg": "No data available for date: 2024-01-02"}`}},
+			calls:     1,
+			err:       nasa_api.ErrNotPublishedYet,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			api, calls := serve(t, tc.responses...)
+
+			apod, err := api.GetAPODByDate(context.Background(), apod_date.MustParse("2024-01-02"))
+			require.Equal(t, tc.calls, int(calls.Load()))
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, apod_date.MustParse("2024-01-02"), apod.Date)
+
+			rateLimit := api.RateLimit()
+			require.Equal(t, 1000, rateLimit.Limit)
+			require.Equal(t, 998, rateLimit.Remaining)
+		})
+	}
+}
+
+func TestNasaApi_GetAPODByDate_NotPublishedYet(t *testing.T) {
+	api, _ := serve(t, response{
+		status: http.StatusBadRequest,
+		body:   `{"code": 400, "msg": "Date must be between Jun 16, 1995 and Jan 01, 2024.", "service_version": "v1"}`,
+	})
+
+	_, err := api.GetAPODByDate(context.Background(), apod_date.MustParse("2024-01-02"))
+	require.ErrorIs(t, err, nasa_api.ErrNotPublishedYet)
+
+	var statusErr *nasa_api.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
+
+func TestNasaApi_StopsRetryingOnCancel(t *testing.T) {
+	api, calls := serve(t, response{status: http.StatusServiceUnavailable})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := api.GetAPODByDate(ctx, apod_date.MustParse("2024-01-02"))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, calls.Load())
+}
+
+func TestNasaApi_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"date":"2024-01-02","title":"Galaxy"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	api := nasa_api.NewNasaApiConnect(srv.URL, "token", time.Second, retry)
+	_, err := api.GetAPODByDate(ctx, apod_date.MustParse("2024-01-02"))
+	require.NoError(t, err)
+
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceparent)
+}
+
+func TestNasaApi_KeepsAPIKeyOutOfErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	// Requests to a closed server fail in the transport.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	api := nasa_api.NewNasaApiConnect(srv.URL, "secret-token", time.Second, backoff.Policy{MaxAttempts: 1})
+
+	_, err := api.GetAPODByDate(context.Background(), apod_date.MustParse("2024-01-02"))
+	require.Error(t, err)
+	var urlErr *url.Error
+	require.ErrorAs(t, err, &urlErr)
+	require.NotContains(t, err.Error(), "secret-token")
+
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+	for _, span := range spans {
+		require.Equal(t, codes.Error, span.Status().Code)
+		require.NotContains(t, span.Status().Description, "secret-token")
+		for _, event := range span.Events() {
+			for _, attr := range event.Attributes {
+				require.False(t, strings.Contains(attr.Value.Emit(), "secret-token"), "event %s records the key", event.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package cached
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"stellar_journal/internal/cache_store"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/logger/sl"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"strconv"
+	"time"
+)
+
+const keyPrefix = "stellar_journal:"
+
+// journalVersionKey holds the version that keys of cached journal pages
+// include. Changing it invalidates every page at once.
+const journalVersionKey = keyPrefix + "journal:version"
+
+// Reader is the storage the cache reads through to.
+type Reader interface {
+	GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error)
+	GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error)
+	GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error)
+}
+
+// Storage caches the entries and journal pages read from a Reader for ttl.
+// Failures of the cache are logged and the read goes to the Reader, so the
+// cache never makes reads fail. Random entries and missing entries are not
+// cached.
+type Storage struct {
+	next  Reader
+	store cache_store.Store
+	ttl   time.Duration
+	log   *slog.Logger
+}
+
+func New(next Reader, store cache_store.Store, ttl time.Duration, log *slog.Logger) *Storage {
+	return &Storage{
+		next:  next,
+		store: store,
+		ttl:   ttl,
+		log:   log.With(slog.String("op", "internal/storage/cached")),
+	}
+}
+
+func (s *Storage) GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	key := apodKey(source, date)
+
+	var apod stellar_journal_models.APOD
+	if s.get(ctx, key, &apod) {
+		return &apod, nil
+	}
+
+	fetched, err := s.next.GetAPOD(ctx, source, date)
+	if err != nil {
+		return nil, err
+	}
+	s.set(ctx, key, fetched)
+
+	return fetched, nil
+}
+
+func (s *Storage) GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error) {
+	return s.next.GetRandomAPOD(ctx, source)
+}
+
+func (s *Storage) GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error) {
+	version, err := s.journalVersion(ctx)
+	if err != nil {
+		s.log.Warn("failed to get journal version", sl.Err(err))
+		return s.next.GetJournal(ctx, query)
+	}
+
+	key, err := journalKey(version, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var page storage.JournalPage
+	if s.get(ctx, key, &page) {
+		return &page, nil
+	}
+
+	fetched, err := s.next.GetJournal(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.set(ctx, key, fetched)
+
+	return fetched, nil
+}
+
+// Invalidate drops the cached entry of the APOD and every cached journal page.
+func (s *Storage) Invalidate(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	const op = "internal/storage/cached.Invalidate"
+
+	if err := s.store.Delete(ctx, apodKey(apod.Source, apod.Date)); err != nil {
+		return fmt.Errorf("%s: failed to delete entry: %w", op, err)
+	}
+	if err := s.InvalidateJournal(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// InvalidateJournal drops every cached journal page. Entries are kept.
+func (s *Storage) InvalidateJournal(ctx context.Context) error {
+	const op = "internal/storage/cached.InvalidateJournal"
+
+	if _, err := s.newJournalVersion(ctx); err != nil {
+		return fmt.Errorf("%s: failed to invalidate journal: %w", op, err)
+	}
+
+	return nil
+}
+
+// get decodes the cached value of key into v and reports whether it was cached.
+func (s *Storage) get(ctx context.Context, key string, v any) bool {
+	data, err := s.store.Get(ctx, key)
+	if errors.Is(err, cache_store.ErrMiss) {
+		return false
+	}
+	if err != nil {
+		s.log.Warn("failed to read cache", slog.String("key", key), sl.Err(err))
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		s.log.Warn("failed to decode cached value", slog.String("key", key), sl.Err(err))
+		return false
+	}
+
+	return true
+}
+
+func (s *Storage) set(ctx context.Context, key string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.log.Warn("failed to encode value", slog.String("key", key), sl.Err(err))
+		return
+	}
+
+	if err := s.store.Set(ctx, key, data, s.ttl); err != nil {
+		s.log.Warn("failed to write cache", slog.String("key", key), sl.Err(err))
+	}
+}
+
+// journalVersion returns the current journal version. A version that is
+// missing, e.g. because it was evicted, is replaced by a new one so that pages
+// cached under an earlier version are never served again.
+func (s *Storage) journalVersion(ctx context.Context) (string, error) {
+	version, err := s.store.Get(ctx, journalVersionKey)
+	if errors.Is(err, cache_store.ErrMiss) {
+		return s.newJournalVersion(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(version), nil
+}
+
+func (s *Storage) newJournalVersion(ctx context.Context) (string, error) {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.store.Set(ctx, journalVersionKey, []byte(version), 0); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+func apodKey(source string, date apod_date.Date) string {
+	return fmt.Sprintf("%sapod:%s:%s", keyPrefix, source, date)
+}
+
+func journalKey(version string, query storage.JournalQuery) (string, error) {
+	const op = "internal/storage/cached.journalKey"
+
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to encode query: %w", op, err)
+	}
+	sum := sha256.Sum256(data)
+
+	return fmt.Sprintf("%sjournal:%s:%s", keyPrefix, version, hex.EncodeToString(sum[:16])), nil
+}
@@ -0,0 +1,202 @@
+package cached_test
+
+import (
+	"context"
+	"errors"
+	"stellar_journal/internal/cache_store/lru"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/lib/clock"
+	"stellar_journal/internal/lib/logger/handlers/slogdiscard"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"stellar_journal/internal/storage/cached"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReader struct {
+	mock.Mock
+}
+
+func (m *MockReader) GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error) {
+	args := m.Called(ctx, source, date)
+	apod, _ := args.Get(0).(*stellar_journal_models.APOD)
+	return apod, args.Error(1)
+}
+
+func (m *MockReader) GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error) {
+	args := m.Called(ctx, source)
+	apod, _ := args.Get(0).(*stellar_journal_models.APOD)
+	return apod, args.Error(1)
+}
+
+func (m *MockReader) GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error) {
+	args := m.Called(ctx, query)
+	page, _ := args.Get(0).(*storage.JournalPage)
+	return page, args.Error(1)
+}
+
+// failingStore is a cache_store.Store whose server is down.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingStore) Delete(context.Context, ...string) error {
+	return errors.New("connection refused")
+}
+
+var (
+	date = apod_date.MustParse("2024-01-02")
+	apod = &stellar_journal_models.APOD{
+		Source:    stellar_journal_models.SourceNASAAPOD,
+		Date:      date,
+		Title:     "Galaxy",
+		UpdatedAt: time.Date(2024, time.January, 2, 5, 0, 0, 0, time.UTC),
+	}
+	query = storage.JournalQuery{Limit: 10, Order: storage.OrderDesc}
+	page  = &storage.JournalPage{
+		APODs:      []stellar_journal_models.APOD{*apod},
+		Total:      1,
+		NextCursor: storage.Cursor{Date: date, Source: stellar_journal_models.SourceNASAAPOD},
+	}
+)
+
+func TestGetAPODReadsThrough(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetAPOD", mock.Anything, apod.Source, date).Return(apod, nil).Twice()
+	reader.On("GetAPOD", mock.Anything, apod.Source, date.AddDays(1)).Return(nil, storage.ErrAPODNotFound).Twice()
+
+	st := cached.New(reader, lru.NewStore(10, clock.System), time.Hour, slogdiscard.NewDiscardLogger())
+
+	for i := 0; i < 2; i++ {
+		got, err := st.GetAPOD(ctx, apod.Source, date)
+		require.NoError(t, err)
+		require.Equal(t, apod.Title, got.Title)
+		require.True(t, apod.UpdatedAt.Equal(got.UpdatedAt))
+
+		_, err = st.GetAPOD(ctx, apod.Source, date.AddDays(1))
+		require.ErrorIs(t, err, storage.ErrAPODNotFound)
+	}
+
+	require.NoError(t, st.Invalidate(ctx, apod))
+
+	_, err := st.GetAPOD(ctx, apod.Source, date)
+	require.NoError(t, err)
+
+	reader.AssertExpectations(t)
+}
+
+func TestGetJournalReadsThrough(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetJournal", mock.Anything, query).Return(page, nil).Twice()
+	other := query
+	other.Source = stellar_journal_models.SourceBing
+	reader.On("GetJournal", mock.Anything, other).Return(&storage.JournalPage{}, nil).Once()
+
+	st := cached.New(reader, lru.NewStore(10, clock.System), time.Hour, slogdiscard.NewDiscardLogger())
+
+	for i := 0; i < 2; i++ {
+		got, err := st.GetJournal(ctx, query)
+		require.NoError(t, err)
+		require.Equal(t, page.Total, got.Total)
+		require.Equal(t, page.NextCursor, got.NextCursor)
+		require.Len(t, got.APODs, 1)
+	}
+
+	_, err := st.GetJournal(ctx, other)
+	require.NoError(t, err)
+
+	require.NoError(t, st.Invalidate(ctx, apod))
+
+	_, err = st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	reader.AssertExpectations(t)
+}
+
+func TestInvalidateJournalKeepsEntries(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetAPOD", mock.Anything, apod.Source, date).Return(apod, nil).Once()
+	reader.On("GetJournal", mock.Anything, query).Return(page, nil).Twice()
+
+	st := cached.New(reader, lru.NewStore(10, clock.System), time.Hour, slogdiscard.NewDiscardLogger())
+
+	_, err := st.GetAPOD(ctx, apod.Source, date)
+	require.NoError(t, err)
+	_, err = st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	require.NoError(t, st.InvalidateJournal(ctx))
+
+	_, err = st.GetAPOD(ctx, apod.Source, date)
+	require.NoError(t, err)
+	_, err = st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	reader.AssertExpectations(t)
+}
+
+func TestEvictedJournalVersionInvalidatesPages(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetJournal", mock.Anything, query).Return(page, nil).Twice()
+
+	store := lru.NewStore(10, clock.System)
+	st := cached.New(reader, store, time.Hour, slogdiscard.NewDiscardLogger())
+
+	_, err := st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(ctx, "stellar_journal:journal:version"))
+
+	_, err = st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	reader.AssertExpectations(t)
+}
+
+func TestGetRandomAPODIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetRandomAPOD", mock.Anything, apod.Source).Return(apod, nil).Twice()
+
+	st := cached.New(reader, lru.NewStore(10, clock.System), time.Hour, slogdiscard.NewDiscardLogger())
+
+	for i := 0; i < 2; i++ {
+		_, err := st.GetRandomAPOD(ctx, apod.Source)
+		require.NoError(t, err)
+	}
+
+	reader.AssertExpectations(t)
+}
+
+func TestFailingStoreFallsBackToReader(t *testing.T) {
+	ctx := context.Background()
+	reader := &MockReader{}
+	reader.On("GetAPOD", mock.Anything, apod.Source, date).Return(apod, nil).Once()
+	reader.On("GetJournal", mock.Anything, query).Return(page, nil).Once()
+
+	st := cached.New(reader, failingStore{}, time.Hour, slogdiscard.NewDiscardLogger())
+
+	_, err := st.GetAPOD(ctx, apod.Source, date)
+	require.NoError(t, err)
+
+	_, err = st.GetJournal(ctx, query)
+	require.NoError(t, err)
+
+	require.Error(t, st.Invalidate(ctx, apod))
+
+	reader.AssertExpectations(t)
+}
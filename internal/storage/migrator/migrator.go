@@ -1,10 +1,12 @@
 package migrator
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -13,6 +15,9 @@ import (
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
+// versionTable is where golang-migrate keeps the schema version.
+const versionTable = "schema_migrations"
+
 type DatabaseDriver interface {
 	Open(db *sql.DB) (database.Driver, error)
 }
@@ -20,6 +25,7 @@ type DatabaseDriver interface {
 type Migrator struct {
 	srcDriver source.Driver
 	dbDriver  DatabaseDriver
+	latest    uint
 }
 
 func NewMigrator(sqlFiles embed.FS, dirName string, dbDriver DatabaseDriver) (*Migrator, error) {
@@ -29,12 +35,70 @@ func NewMigrator(sqlFiles embed.FS, dirName string, dbDriver DatabaseDriver) (*M
 	if err != nil {
 		return nil, fmt.Errorf("%s: unable to create new iofs: %w", op, err)
 	}
+
+	latest, err := latestVersion(d)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to read migrations: %w", op, err)
+	}
+
 	return &Migrator{
 		srcDriver: d,
 		dbDriver:  dbDriver,
+		latest:    latest,
 	}, nil
 }
 
+// Latest returns the version of the newest migration, which the schema has
+// once ApplyMigrations succeeded.
+func (m *Migrator) Latest() uint {
+	return m.latest
+}
+
+// Version returns the version of the schema of db and whether the last
+// migration applied to it failed. The version is 0 before any migration.
+//
+// The version table is read directly: opening a migration driver would take
+// the migration lock, which blocks while another instance migrates.
+func (m *Migrator) Version(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	const op = "/internal/storage/migrator.Version"
+
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM `+versionTable+` LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: unable to get version: %w", op, err)
+	}
+	if version < 0 {
+		return 0, false, nil
+	}
+
+	return uint(version), dirty, nil
+}
+
+func latestVersion(d source.Driver) (uint, error) {
+	version, err := d.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := d.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
 func (m *Migrator) ApplyMigrations(db *sql.DB, dbName string) error {
 	const op = "/internal/storage/migrator.ApplyMigrations"
 
@@ -0,0 +1,175 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"stellar_journal/internal/storage"
+	"time"
+)
+
+const apiKeyColumns = `k.id, k.name, k.key_prefix, k.admin, k.daily_quota, k.created_at, k.last_used_at, k.revoked_at`
+
+func scanAPIKey(row rowScanner, extra ...any) (*stellar_journal_models.APIKey, error) {
+	var key stellar_journal_models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+
+	dest := append([]any{&key.ID, &key.Name, &key.Prefix, &key.Admin, &key.DailyQuota, &key.CreatedAt, &lastUsedAt, &revokedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+// CreateAPIKey stores a key by its hash and fills in the ID and CreatedAt of
+// key.
+func (s *Storage) CreateAPIKey(ctx context.Context, key *stellar_journal_models.APIKey, hash []byte) error {
+	const op = "internal/storage/postgresql.CreateAPIKey"
+	ctx, done := s.instrument(ctx, "CreateAPIKey")
+	defer done()
+
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, key_prefix, key_hash, admin, daily_quota)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, key.Name, key.Prefix, hash, key.Admin, key.DailyQuota).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert data: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash returns the key with the hash, including revoked ones.
+func (s *Storage) GetAPIKeyByHash(ctx context.Context, hash []byte) (*stellar_journal_models.APIKey, error) {
+	const op = "internal/storage/postgresql.GetAPIKeyByHash"
+	ctx, done := s.instrument(ctx, "GetAPIKeyByHash")
+	defer done()
+
+	key, err := scanAPIKey(s.DB.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys k
+		WHERE k.key_hash = $1
+	`, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys returns every key with its requests of the UTC day of now.
+func (s *Storage) ListAPIKeys(ctx context.Context, now time.Time) ([]stellar_journal_models.APIKey, error) {
+	const op = "internal/storage/postgresql.ListAPIKeys"
+	ctx, done := s.instrument(ctx, "ListAPIKeys")
+	defer done()
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`, coalesce(u.requests, 0)
+		FROM api_keys k
+		LEFT JOIN api_key_usage u ON u.api_key_id = k.id AND u.day = $1
+		ORDER BY k.id
+	`, usageDay(now))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := []stellar_journal_models.APIKey{}
+	for rows.Next() {
+		var requests int64
+		key, err := scanAPIKey(rows, &requests)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		key.RequestsToday = requests
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey rejects the key from now on. Revoking a revoked key keeps the
+// time it was first revoked.
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "internal/storage/postgresql.RevokeAPIKey"
+	ctx, done := s.instrument(ctx, "RevokeAPIKey")
+	defer done()
+
+	res, err := s.DB.ExecContext(ctx, `UPDATE api_keys SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update data: %w", op, err)
+	}
+
+	return affectedAPIKey(op, res)
+}
+
+// SetAPIKeyQuota changes the daily quota of the key; 0 is unlimited.
+func (s *Storage) SetAPIKeyQuota(ctx context.Context, id int, dailyQuota int) error {
+	const op = "internal/storage/postgresql.SetAPIKeyQuota"
+	ctx, done := s.instrument(ctx, "SetAPIKeyQuota")
+	defer done()
+
+	res, err := s.DB.ExecContext(ctx, `UPDATE api_keys SET daily_quota = $2 WHERE id = $1`, id, dailyQuota)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update data: %w", op, err)
+	}
+
+	return affectedAPIKey(op, res)
+}
+
+// RecordAPIKeyUsage counts a request made with the key at now and returns the
+// requests of its UTC day, this one included.
+func (s *Storage) RecordAPIKeyUsage(ctx context.Context, id int, now time.Time) (int64, error) {
+	const op = "internal/storage/postgresql.RecordAPIKeyUsage"
+	ctx, done := s.instrument(ctx, "RecordAPIKeyUsage")
+	defer done()
+
+	var requests int64
+	err := s.DB.QueryRowContext(ctx, `
+		WITH used AS (
+			UPDATE api_keys SET last_used_at = $3 WHERE id = $1
+		)
+		INSERT INTO api_key_usage (api_key_id, day, requests)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (api_key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+		RETURNING requests
+	`, id, usageDay(now), now).Scan(&requests)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update data: %w", op, err)
+	}
+
+	return requests, nil
+}
+
+// usageDay is the UTC day requests at t are counted in.
+func usageDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func affectedAPIKey(op string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"stellar_journal/internal/lib/backoff"
+	"stellar_journal/internal/lib/logger/sl"
+	"strconv"
+	"time"
+)
+
+// Config describes the connection pool. URL, a postgres:// URL or a key=value
+// connection string, takes precedence over the individual connection fields.
+type Config struct {
+	URL         string
+	Host        string
+	Port        int
+	Name        string
+	User        string
+	Password    string
+	SSLMode     string
+	SSLRootCert string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectRetry and ConnectTimeout bound how long Open waits for the
+	// database to accept connections.
+	ConnectRetry   backoff.Policy
+	ConnectTimeout time.Duration
+}
+
+// DSN returns the connection string of the config. Credentials are escaped, so
+// they may contain spaces and other special characters.
+func (c Config) DSN() (string, error) {
+	const op = "internal/storage/postgresql.DSN"
+
+	if c.URL != "" {
+		return c.URL, nil
+	}
+	if c.Host == "" || c.Name == "" {
+		return "", fmt.Errorf("%s: either the URL or the host and database name must be set", op)
+	}
+
+	host := c.Host
+	if c.Port != 0 {
+		host = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	}
+
+	u := url.URL{Scheme: "postgres", Host: host, Path: "/" + c.Name}
+	if c.Password != "" {
+		u.User = url.UserPassword(c.User, c.Password)
+	} else if c.User != "" {
+		u.User = url.User(c.User)
+	}
+
+	query := url.Values{}
+	if c.SSLMode != "" {
+		query.Set("sslmode", c.SSLMode)
+	}
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Open creates the connection pool shared by the migrations and the storage and
+// pings the database, retrying until it is reachable or ConnectTimeout passes.
+func Open(ctx context.Context, cfg Config, log *slog.Logger) (*sql.DB, error) {
+	const op = "internal/storage/postgresql.Open"
+
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := ping(ctx, db, cfg.ConnectRetry, cfg.ConnectTimeout, log); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+func ping(ctx context.Context, db *sql.DB, retry backoff.Policy, timeout time.Duration, log *slog.Logger) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if retry.MaxAttempts > 0 && attempt >= retry.MaxAttempts {
+			return fmt.Errorf("database is unreachable: %w", err)
+		}
+
+		delay := retry.Delay(attempt)
+		log.Warn("database is unreachable, retrying", slog.Int("attempt", attempt), slog.Duration("delay", delay), sl.Err(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("database is unreachable: %w", errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
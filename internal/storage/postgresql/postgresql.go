@@ -1,75 +1,286 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
-	"stellar_journal/internal/models/nasa_api_models"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"html"
+	"slices"
+	"stellar_journal/internal/lib/apod_date"
 	"stellar_journal/internal/models/stellar_journal_models"
 	"stellar_journal/internal/storage"
+	"strings"
+	"time"
 )
 
+const apodColumns = `id, source, copyright, apod_date, explanation, hdurl, media_type, service_version, thumbnail_url, title, url, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// apodFields returns the scan destinations matching apodColumns.
+func apodFields(apod *stellar_journal_models.APOD) []any {
+	return []any{&apod.Id, &apod.Source, &apod.Copyright, &apod.Date, &apod.Explanation, &apod.Hdurl, &apod.MediaType, &apod.ServiceVersion, &apod.ThumbnailUrl, &apod.Title, &apod.Url, &apod.UpdatedAt}
+}
+
+func scanAPOD(row rowScanner) (*stellar_journal_models.APOD, error) {
+	var apod stellar_journal_models.APOD
+	if err := row.Scan(apodFields(&apod)...); err != nil {
+		return nil, err
+	}
+	setMedia(&apod)
+
+	return &apod, nil
+}
+
+func setMedia(apod *stellar_journal_models.APOD) {
+	apod.Media = stellar_journal_models.NewMedia(apod.MediaType, apod.Url, apod.Hdurl, apod.ThumbnailUrl)
+}
+
+// highlight escapes a headline of SearchJournal, whose matches ts_headline
+// delimits with control characters that the text was stripped of, and marks
+// the matches. The text is stored as given by the sources, markup included.
+func highlight(headline string) string {
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(html.EscapeString(headline))
+}
+
 type PostgresDriver struct{}
 
+// Open returns a migration driver on a dedicated connection of db. Closing the
+// driver releases that connection only, so db can be shared with the storage.
 func (d *PostgresDriver) Open(db *sql.DB) (database.Driver, error) {
-	return postgres.WithInstance(db, &postgres.Config{})
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return driver, nil
 }
 
+var tracer = otel.Tracer("stellar_journal/internal/storage/postgresql")
+
+// QueryObserver receives how long a storage method spent in the database.
+type QueryObserver func(method string, duration time.Duration)
+
 type Storage struct {
 	DB     *sql.DB
 	Driver *PostgresDriver
+
+	observeQuery QueryObserver
 }
 
-func NewStorage(user, pass, name, host string) (*Storage, error) {
-	const op = "internal/storage/postgresql.NewStorage"
+// NewStorage returns a storage using the pool created by Open. Every method
+// reports its duration to observe unless observe is nil.
+func NewStorage(db *sql.DB, observe QueryObserver) *Storage {
+	return &Storage{DB: db, observeQuery: observe}
+}
+
+// instrument starts the span of a storage method. The returned function ends
+// it and reports the duration of the method to the query observer.
+func (s *Storage) instrument(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "postgresql."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(method)),
+	)
+
+	return ctx, func() {
+		span.End()
+		if s.observeQuery != nil {
+			s.observeQuery(method, time.Since(start))
+		}
+	}
+}
+
+func (s *Storage) SaveAPOD(ctx context.Context, apod *stellar_journal_models.APOD) error {
+	const op = "internal/storage/postgresql.SaveAPOD"
+	ctx, done := s.instrument(ctx, "SaveAPOD")
+	defer done()
 
-	db, err := sql.Open("postgres", fmt.Sprintf("user=%s password=%s dbname=%s host=%s sslmode=disable", user, pass, name, host))
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO nasa_apod (source, copyright, apod_date, explanation, hdurl, media_type, service_version, thumbnail_url, title, url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, apod.Source, apod.Copyright, apod.Date, apod.Explanation, apod.Hdurl, apod.MediaType, apod.ServiceVersion, apod.ThumbnailUrl, apod.Title, apod.Url)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to connect to the database: %w", op, err)
+		if postgresErr, ok := err.(*pq.Error); ok && postgresErr.Code == "23505" {
+			return fmt.Errorf("%s: failed to insert data: %w", op, storage.ErrAPODExists)
+		}
+		return fmt.Errorf("%s: failed to insert data: %w", op, err)
 	}
 
-	return &Storage{DB: db}, nil
+	return nil
 }
 
-func (s *Storage) SaveAPOD(apod *nasa_api_models.APODResp) error {
-	const op = "internal/storage/postgresql.SaveAPOD"
+// UpsertAPOD stores the APOD, overwriting the stored entry of its source and
+// date when its content differs, and reports what it did. The prior version of
+// an overwritten entry is kept as a revision. The ID and UpdatedAt of apod are
+// filled in. Archived media of an overwritten entry are kept.
+func (s *Storage) UpsertAPOD(ctx context.Context, apod *stellar_journal_models.APOD) (storage.Upsert, error) {
+	const op = "internal/storage/postgresql.UpsertAPOD"
+	ctx, done := s.instrument(ctx, "UpsertAPOD")
+	defer done()
+
+	var created bool
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO nasa_apod (source, copyright, apod_date, explanation, hdurl, media_type, service_version, thumbnail_url, title, url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (source, apod_date) DO UPDATE
+		SET copyright = EXCLUDED.copyright,
+			explanation = EXCLUDED.explanation,
+			hdurl = EXCLUDED.hdurl,
+			media_type = EXCLUDED.media_type,
+			service_version = EXCLUDED.service_version,
+			thumbnail_url = EXCLUDED.thumbnail_url,
+			title = EXCLUDED.title,
+			url = EXCLUDED.url
+		WHERE (nasa_apod.copyright, nasa_apod.explanation, nasa_apod.hdurl, nasa_apod.media_type, nasa_apod.service_version, nasa_apod.thumbnail_url, nasa_apod.title, nasa_apod.url)
+			IS DISTINCT FROM (EXCLUDED.copyright, EXCLUDED.explanation, EXCLUDED.hdurl, EXCLUDED.media_type, EXCLUDED.service_version, EXCLUDED.thumbnail_url, EXCLUDED.title, EXCLUDED.url)
+		RETURNING id, updated_at, xmax = 0
+	`, apod.Source, apod.Copyright, apod.Date, apod.Explanation, apod.Hdurl, apod.MediaType, apod.ServiceVersion, apod.ThumbnailUrl, apod.Title, apod.Url).Scan(&apod.Id, &apod.UpdatedAt, &created)
+	if err == sql.ErrNoRows {
+		// The conflicting row was not updated, so none was returned.
+		err = s.DB.QueryRowContext(ctx, `
+			SELECT id, updated_at
+			FROM nasa_apod
+			WHERE source = $1 AND apod_date = $2
+		`, apod.Source, apod.Date).Scan(&apod.Id, &apod.UpdatedAt)
+		if err != nil {
+			return storage.UpsertUnchanged, fmt.Errorf("%s: failed to get unchanged entry: %w", op, err)
+		}
 
-	stmt, err := s.DB.Prepare(`
-		INSERT INTO nasa_apod (copyright, apod_date, explanation, hdurl, media_type, service_version, title, url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`)
+		return storage.UpsertUnchanged, nil
+	}
 	if err != nil {
-		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+		return storage.UpsertUnchanged, fmt.Errorf("%s: failed to upsert data: %w", op, err)
 	}
+	if created {
+		return storage.UpsertCreated, nil
+	}
+
+	return storage.UpsertUpdated, nil
+}
+
+// GetAPODRevisions returns the prior versions of the entry of the source and
+// date, the most recently replaced first.
+func (s *Storage) GetAPODRevisions(ctx context.Context, source string, date apod_date.Date) ([]stellar_journal_models.APODRevision, error) {
+	const op = "internal/storage/postgresql.GetAPODRevisions"
+	ctx, done := s.instrument(ctx, "GetAPODRevisions")
+	defer done()
 
-	_, err = stmt.Exec(apod.Copyright, apod.Date, apod.Explanation, apod.Hdurl, apod.MediaType, apod.ServiceVersion, apod.Title, apod.Url)
+	var apodID int
+	err := s.DB.QueryRowContext(ctx, `SELECT id FROM nasa_apod WHERE source = $1 AND apod_date = $2`, source, date).Scan(&apodID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, storage.ErrAPODNotFound)
+	}
 	if err != nil {
-		if postgresErr, ok := err.(*pq.Error); ok && postgresErr.Code == "23505" {
-			return fmt.Errorf("%s: failed to insert data: %w", op, storage.ErrAPODExists)
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, copyright, explanation, hdurl, media_type, service_version, thumbnail_url, title, url, updated_at, replaced_at
+		FROM nasa_apod_revisions
+		WHERE apod_id = $1
+		ORDER BY replaced_at DESC, id DESC
+	`, apodID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get revisions: %w", op, err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	revisions := []stellar_journal_models.APODRevision{}
+	for rows.Next() {
+		var r stellar_journal_models.APODRevision
+		if err := rows.Scan(&r.Id, &r.Copyright, &r.Explanation, &r.Hdurl, &r.MediaType, &r.ServiceVersion, &r.ThumbnailUrl, &r.Title, &r.Url, &r.UpdatedAt, &r.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan revision: %w", op, err)
 		}
-		return fmt.Errorf("%s: failed to insert data: %w", op, err)
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate revisions: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// DeleteAPOD deletes the entry of the source and date together with the
+// records of its archived media.
+func (s *Storage) DeleteAPOD(ctx context.Context, source string, date apod_date.Date) error {
+	const op = "internal/storage/postgresql.DeleteAPOD"
+	ctx, done := s.instrument(ctx, "DeleteAPOD")
+	defer done()
+
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM nasa_apod WHERE source = $1 AND apod_date = $2`, source, date)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete data: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAPODNotFound)
 	}
 
 	return nil
 }
 
-func (s *Storage) GetAPOD(date string) (*stellar_journal_models.APOD, error) {
+func (s *Storage) GetAPOD(ctx context.Context, source string, date apod_date.Date) (*stellar_journal_models.APOD, error) {
 	const op = "internal/storage/postgresql.GetAPOD"
+	ctx, done := s.instrument(ctx, "GetAPOD")
+	defer done()
 
-	stmt, err := s.DB.Prepare(`
-		SELECT id, copyright, apod_date, explanation, hdurl, media_type, service_version, title, url
+	apod, err := scanAPOD(s.DB.QueryRowContext(ctx, `
+		SELECT `+apodColumns+`
 		FROM nasa_apod
-		WHERE apod_date = $1
-	`)
+		WHERE source = $1 AND apod_date = $2
+	`, source, date))
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: failed to get data: %w", op, storage.ErrAPODNotFound)
+		}
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
 	}
 
-	var apod stellar_journal_models.APOD
-	err = stmt.QueryRow(date).Scan(&apod.Id, &apod.Copyright, &apod.Date, &apod.Explanation, &apod.Hdurl, &apod.MediaType, &apod.ServiceVersion, &apod.Title, &apod.Url)
+	if err := s.attachDerivatives(ctx, apod); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apod, nil
+}
+
+// GetRandomAPOD returns a uniformly chosen stored APOD of the source.
+func (s *Storage) GetRandomAPOD(ctx context.Context, source string) (*stellar_journal_models.APOD, error) {
+	const op = "internal/storage/postgresql.GetRandomAPOD"
+	ctx, done := s.instrument(ctx, "GetRandomAPOD")
+	defer done()
+
+	apod, err := scanAPOD(s.DB.QueryRowContext(ctx, `
+		SELECT `+apodColumns+`
+		FROM nasa_apod
+		WHERE source = $1
+		ORDER BY apod_date
+		OFFSET floor(random() * (SELECT count(*) FROM nasa_apod WHERE source = $1))
+		LIMIT 1
+	`, source))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("%s: failed to get data: %w", op, storage.ErrAPODNotFound)
@@ -77,22 +288,385 @@ func (s *Storage) GetAPOD(date string) (*stellar_journal_models.APOD, error) {
 		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
 	}
 
-	return &apod, nil
+	if err := s.attachDerivatives(ctx, apod); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apod, nil
 }
 
-func (s *Storage) GetJournal() (*[]stellar_journal_models.APOD, error) {
+// GetJournal returns a page of the journal using keyset pagination on
+// (apod_date, source).
+func (s *Storage) GetJournal(ctx context.Context, query storage.JournalQuery) (*storage.JournalPage, error) {
 	const op = "internal/storage/postgresql.GetJournal"
+	ctx, done := s.instrument(ctx, "GetJournal")
+	defer done()
+
+	conds, args := journalFilter(query.JournalFilter)
+
+	countQuery := `SELECT count(*) FROM nasa_apod`
+	if len(conds) > 0 {
+		countQuery += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := s.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("%s: failed to count data: %w", op, err)
+	}
+
+	desc := query.Order != storage.OrderAsc
+	// Paging backwards scans in the opposite direction and reverses the result.
+	backward := !query.Before.IsZero()
+
+	cursor, cmp := query.After, ">"
+	if backward {
+		cursor = query.Before
+	}
+	if desc != backward {
+		cmp = "<"
+	}
+
+	scanDesc := desc != backward
+	direction := "ASC"
+	if scanDesc {
+		direction = "DESC"
+	}
+
+	if !cursor.IsZero() && cursor.Source != "" {
+		args = append(args, cursor.Date, cursor.Source)
+		conds = append(conds, fmt.Sprintf("(apod_date, source) %s ($%d, $%d)", cmp, len(args)-1, len(args)))
+	} else if !cursor.IsZero() {
+		args = append(args, cursor.Date)
+		conds = append(conds, fmt.Sprintf("apod_date %s $%d", cmp, len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, query.Limit+1)
+
+	listQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM nasa_apod
+		%s
+		ORDER BY apod_date %[3]s, source %[3]s
+		LIMIT $%[4]d
+	`, apodColumns, where, direction, len(args))
+
+	rows, err := s.DB.QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			fmt.Printf("%s: failed to close rows: %v\n", op, err)
+		}
+	}(rows)
+
+	apods := make([]stellar_journal_models.APOD, 0, query.Limit+1)
+	for rows.Next() {
+		apod, err := scanAPOD(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan data: %w", op, err)
+		}
+		apods = append(apods, *apod)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	hasMore := len(apods) > query.Limit
+	if hasMore {
+		apods = apods[:query.Limit]
+	}
+	if backward {
+		slices.Reverse(apods)
+	}
+
+	refs := make([]*stellar_journal_models.APOD, len(apods))
+	for i := range apods {
+		refs[i] = &apods[i]
+	}
+	if err := s.attachDerivatives(ctx, refs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &storage.JournalPage{APODs: apods, Total: total}
+	if len(apods) == 0 {
+		return page, nil
+	}
+
+	first := storage.Cursor{Date: apods[0].Date, Source: apods[0].Source}
+	last := storage.Cursor{Date: apods[len(apods)-1].Date, Source: apods[len(apods)-1].Source}
+	if backward {
+		page.NextCursor = last
+		if hasMore {
+			page.PrevCursor = first
+		}
+	} else {
+		if hasMore {
+			page.NextCursor = last
+		}
+		if !query.After.IsZero() {
+			page.PrevCursor = first
+		}
+	}
+
+	return page, nil
+}
 
-	stmt, err := s.DB.Prepare(`
-		SELECT id, copyright, apod_date, explanation, hdurl, media_type, service_version, title, url
+// SearchJournal runs a full-text search over titles and explanations. The query
+// uses the web search syntax ("quoted phrases", -exclusions, or). Highlights
+// are HTML: the text is escaped and matches are wrapped in <mark> tags.
+func (s *Storage) SearchJournal(ctx context.Context, query string, limit int) ([]stellar_journal_models.SearchResult, error) {
+	const op = "internal/storage/postgresql.SearchJournal"
+	ctx, done := s.instrument(ctx, "SearchJournal")
+	defer done()
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+apodColumns+`,
+			ts_rank(search_vector, q),
+			ts_headline('english', translate(coalesce(title, ''), E'\x02\x03', ''), q, E'HighlightAll=true, StartSel=\x02, StopSel=\x03'),
+			ts_headline('english', translate(coalesce(explanation, ''), E'\x02\x03', ''), q, E'MaxFragments=2, MinWords=10, MaxWords=30, StartSel=\x02, StopSel=\x03')
+		FROM nasa_apod, websearch_to_tsquery('english', $1) AS q
+		WHERE search_vector @@ q
+		ORDER BY ts_rank(search_vector, q) DESC, apod_date DESC
+		LIMIT $2
+	`, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			fmt.Printf("%s: failed to close rows: %v\n", op, err)
+		}
+	}(rows)
+
+	results := make([]stellar_journal_models.SearchResult, 0, limit)
+	for rows.Next() {
+		var result stellar_journal_models.SearchResult
+		fields := append(apodFields(&result.APOD), &result.Rank, &result.TitleHighlight, &result.Snippet)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan data: %w", op, err)
+		}
+		setMedia(&result.APOD)
+		result.TitleHighlight = highlight(result.TitleHighlight)
+		result.Snippet = highlight(result.Snippet)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	refs := make([]*stellar_journal_models.APOD, len(results))
+	for i := range results {
+		refs[i] = &results[i].APOD
+	}
+	if err := s.attachDerivatives(ctx, refs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// SaveAPODMedia records an archived image of the APOD with the given source and
+// date, replacing the previous record of the same variant.
+func (s *Storage) SaveAPODMedia(ctx context.Context, media *stellar_journal_models.APODMedia) error {
+	const op = "internal/storage/postgresql.SaveAPODMedia"
+	ctx, done := s.instrument(ctx, "SaveAPODMedia")
+	defer done()
+
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO apod_media (apod_id, variant, source_url, storage_key, checksum, byte_size, content_type)
+		SELECT id, $3, $4, $5, $6, $7, $8
 		FROM nasa_apod
-		ORDER BY apod_date DESC
-	`)
+		WHERE source = $1 AND apod_date = $2
+		ON CONFLICT (apod_id, variant) DO UPDATE
+		SET source_url = EXCLUDED.source_url,
+			storage_key = EXCLUDED.storage_key,
+			checksum = EXCLUDED.checksum,
+			byte_size = EXCLUDED.byte_size,
+			content_type = EXCLUDED.content_type,
+			created_at = now()
+	`, media.Source, media.Date, media.Variant, media.SourceURL, media.StorageKey, media.Checksum, media.ByteSize, media.ContentType)
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert data: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%s: failed to insert data: %w", op, storage.ErrAPODNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetAPODMedia(ctx context.Context, source string, date apod_date.Date, variant string) (*stellar_journal_models.APODMedia, error) {
+	const op = "internal/storage/postgresql.GetAPODMedia"
+	ctx, done := s.instrument(ctx, "GetAPODMedia")
+	defer done()
+
+	var media stellar_journal_models.APODMedia
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT a.source, a.apod_date, m.variant, m.source_url, m.storage_key, m.checksum, m.byte_size, m.content_type, m.created_at
+		FROM apod_media m
+		JOIN nasa_apod a ON a.id = m.apod_id
+		WHERE a.source = $1 AND a.apod_date = $2 AND m.variant = $3
+	`, source, date, variant).Scan(&media.Source, &media.Date, &media.Variant, &media.SourceURL, &media.StorageKey, &media.Checksum, &media.ByteSize, &media.ContentType, &media.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: failed to get data: %w", op, storage.ErrMediaNotFound)
+		}
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+
+	return &media, nil
+}
+
+// SaveAPODDerivative records a resized image of the APOD with the given source
+// and date, replacing the previous record of the same width and format.
+func (s *Storage) SaveAPODDerivative(ctx context.Context, derivative *stellar_journal_models.APODDerivative) error {
+	const op = "internal/storage/postgresql.SaveAPODDerivative"
+	ctx, done := s.instrument(ctx, "SaveAPODDerivative")
+	defer done()
+
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO apod_image_derivatives (apod_id, width, format, storage_key, checksum, byte_size, content_type)
+		SELECT id, $3, $4, $5, $6, $7, $8
+		FROM nasa_apod
+		WHERE source = $1 AND apod_date = $2
+		ON CONFLICT (apod_id, width, format) DO UPDATE
+		SET storage_key = EXCLUDED.storage_key,
+			checksum = EXCLUDED.checksum,
+			byte_size = EXCLUDED.byte_size,
+			content_type = EXCLUDED.content_type,
+			created_at = now()
+	`, derivative.Source, derivative.Date, derivative.Width, derivative.Format, derivative.StorageKey, derivative.Checksum, derivative.ByteSize, derivative.ContentType)
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert data: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%s: failed to insert data: %w", op, storage.ErrAPODNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetAPODDerivative(ctx context.Context, source string, date apod_date.Date, width int, format string) (*stellar_journal_models.APODDerivative, error) {
+	const op = "internal/storage/postgresql.GetAPODDerivative"
+	ctx, done := s.instrument(ctx, "GetAPODDerivative")
+	defer done()
+
+	var d stellar_journal_models.APODDerivative
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT a.source, a.apod_date, d.width, d.format, d.storage_key, d.checksum, d.byte_size, d.content_type, d.created_at
+		FROM apod_image_derivatives d
+		JOIN nasa_apod a ON a.id = d.apod_id
+		WHERE a.source = $1 AND a.apod_date = $2 AND d.width = $3 AND d.format = $4
+	`, source, date, width, format).Scan(&d.Source, &d.Date, &d.Width, &d.Format, &d.StorageKey, &d.Checksum, &d.ByteSize, &d.ContentType, &d.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: failed to get data: %w", op, storage.ErrMediaNotFound)
+		}
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+
+	return &d, nil
+}
+
+// attachDerivatives loads the image derivatives of the given APODs with one query.
+func (s *Storage) attachDerivatives(ctx context.Context, apods ...*stellar_journal_models.APOD) error {
+	if len(apods) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*stellar_journal_models.APOD, len(apods))
+	ids := make([]int64, 0, len(apods))
+	for _, apod := range apods {
+		byID[apod.Id] = apod
+		ids = append(ids, int64(apod.Id))
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT apod_id, width, format
+		FROM apod_image_derivatives
+		WHERE apod_id = ANY($1)
+		ORDER BY apod_id, format, width
+	`, pq.Array(ids))
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+		return fmt.Errorf("failed to get derivatives: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		var id, width int
+		var format string
+		if err := rows.Scan(&id, &width, &format); err != nil {
+			return fmt.Errorf("failed to scan derivatives: %w", err)
+		}
+		if apod, ok := byID[id]; ok {
+			apod.AddDerivative(width, format)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate derivatives: %w", err)
+	}
+
+	return nil
+}
+
+// journalFilter translates the filter into SQL conditions with positional
+// parameters starting at $1.
+func journalFilter(filter storage.JournalFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
 	}
 
-	rows, err := stmt.Query()
+	if filter.Source != "" {
+		add("source = $%d", filter.Source)
+	}
+	if !filter.From.IsZero() {
+		add("apod_date >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("apod_date <= $%d", filter.To)
+	}
+	if filter.MediaType != "" {
+		add("media_type = $%d", filter.MediaType)
+	}
+	if filter.ServiceVersion != "" {
+		add("service_version = $%d", filter.ServiceVersion)
+	}
+	if filter.Copyright != "" {
+		add("copyright ILIKE '%%' || $%d || '%%'", likeEscaper.Replace(filter.Copyright))
+	}
+
+	return conds, args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetAPODDates returns the dates between startDate and endDate (inclusive)
+// stored for the source.
+func (s *Storage) GetAPODDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	const op = "internal/storage/postgresql.GetAPODDates"
+	ctx, done := s.instrument(ctx, "GetAPODDates")
+	defer done()
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT apod_date
+		FROM nasa_apod
+		WHERE source = $1 AND apod_date BETWEEN $2 AND $3
+		ORDER BY apod_date
+	`, source, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
 	}
@@ -103,17 +677,99 @@ func (s *Storage) GetJournal() (*[]stellar_journal_models.APOD, error) {
 		}
 	}(rows)
 
-	var apods []stellar_journal_models.APOD
+	var dates []apod_date.Date
 	for rows.Next() {
-		var apod stellar_journal_models.APOD
-		err = rows.Scan(&apod.Id, &apod.Copyright, &apod.Date, &apod.Explanation, &apod.Hdurl, &apod.MediaType, &apod.ServiceVersion, &apod.Title, &apod.Url)
+		var date apod_date.Date
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan data: %w", op, err)
+		}
+		dates = append(dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return dates, nil
+}
+
+// GetNoPictureDates returns the dates between startDate and endDate (inclusive)
+// recorded as having no picture of the source, in ascending order.
+func (s *Storage) GetNoPictureDates(ctx context.Context, source string, startDate, endDate apod_date.Date) ([]apod_date.Date, error) {
+	const op = "internal/storage/postgresql.GetNoPictureDates"
+	ctx, done := s.instrument(ctx, "GetNoPictureDates")
+	defer done()
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT apod_date
+		FROM apod_no_picture_days
+		WHERE source = $1 AND apod_date BETWEEN $2 AND $3
+		ORDER BY apod_date
+	`, source, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
 		if err != nil {
+			fmt.Printf("%s: failed to close rows: %v\n", op, err)
+		}
+	}(rows)
+
+	var dates []apod_date.Date
+	for rows.Next() {
+		var date apod_date.Date
+		if err := rows.Scan(&date); err != nil {
 			return nil, fmt.Errorf("%s: failed to scan data: %w", op, err)
 		}
-		apods = append(apods, apod)
+		dates = append(dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return dates, nil
+}
+
+// SaveNoPictureDates records that the source has no picture for the dates.
+// Dates recorded already are ignored.
+func (s *Storage) SaveNoPictureDates(ctx context.Context, source string, dates []apod_date.Date) error {
+	const op = "internal/storage/postgresql.SaveNoPictureDates"
+	ctx, done := s.instrument(ctx, "SaveNoPictureDates")
+	defer done()
+
+	values := make([]string, len(dates))
+	for i, date := range dates {
+		values[i] = date.String()
+	}
+
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO apod_no_picture_days (source, apod_date)
+		SELECT $1, unnest($2::date[])
+		ON CONFLICT DO NOTHING
+	`, source, pq.Array(values))
+	if err != nil {
+		return fmt.Errorf("%s: failed to insert data: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetLatestAPODDate returns the date of the newest stored APOD of the source.
+func (s *Storage) GetLatestAPODDate(ctx context.Context, source string) (apod_date.Date, error) {
+	const op = "internal/storage/postgresql.GetLatestAPODDate"
+	ctx, done := s.instrument(ctx, "GetLatestAPODDate")
+	defer done()
+
+	var date apod_date.Date
+	err := s.DB.QueryRowContext(ctx, `SELECT max(apod_date) FROM nasa_apod WHERE source = $1`, source).Scan(&date)
+	if err != nil {
+		return apod_date.Date{}, fmt.Errorf("%s: failed to get data: %w", op, err)
+	}
+	if date.IsZero() {
+		return apod_date.Date{}, fmt.Errorf("%s: %w", op, storage.ErrAPODNotFound)
 	}
 
-	return &apods, nil
+	return date, nil
 }
 
 func (s *Storage) Close() error {
@@ -1,8 +1,95 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/models/stellar_journal_models"
+	"strings"
+)
 
 var (
-	ErrAPODNotFound = errors.New("APOD not found")
-	ErrAPODExists   = errors.New("APOD exists")
+	ErrAPODNotFound   = errors.New("APOD not found")
+	ErrAPODExists     = errors.New("APOD exists")
+	ErrMediaNotFound  = errors.New("APOD media not found")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// Upsert tells what UpsertAPOD did with an entry.
+type Upsert int
+
+const (
+	// UpsertUnchanged means the stored entry had the same content and was left
+	// alone.
+	UpsertUnchanged Upsert = iota
+	UpsertCreated
+	// UpsertUpdated means the stored entry differed and was replaced; its prior
+	// version is kept as a revision.
+	UpsertUpdated
 )
+
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// Cursor points at a journal entry for keyset pagination. A cursor without a
+// source points at the whole date: paging from it skips every entry of the date.
+type Cursor struct {
+	Date   apod_date.Date
+	Source string
+}
+
+// ParseCursor parses "YYYY-MM-DD" or "YYYY-MM-DD.source". An empty string is the
+// zero Cursor.
+func ParseCursor(s string) (Cursor, error) {
+	date, source, _ := strings.Cut(s, ".")
+
+	var c Cursor
+	if err := c.Date.UnmarshalText([]byte(date)); err != nil {
+		return Cursor{}, err
+	}
+	c.Source = source
+
+	return c, nil
+}
+
+func (c Cursor) String() string {
+	if c.Source == "" {
+		return c.Date.String()
+	}
+
+	return c.Date.String() + "." + c.Source
+}
+
+func (c Cursor) IsZero() bool {
+	return c.Date.IsZero()
+}
+
+// JournalQuery selects a page of the journal. After and Before are cursors
+// returned in a previous JournalPage: After selects the entries following the
+// cursor in the requested order, Before the ones preceding it.
+type JournalQuery struct {
+	Limit  int
+	Order  string
+	After  Cursor
+	Before Cursor
+	JournalFilter
+}
+
+// JournalFilter narrows the journal down. Empty fields are ignored, From and To
+// are inclusive and Copyright matches case-insensitively as a substring.
+type JournalFilter struct {
+	Source         string
+	From           apod_date.Date
+	To             apod_date.Date
+	MediaType      string
+	Copyright      string
+	ServiceVersion string
+}
+
+type JournalPage struct {
+	APODs      []stellar_journal_models.APOD
+	Total      int
+	NextCursor Cursor
+	PrevCursor Cursor
+}
@@ -0,0 +1,40 @@
+package storage_test
+
+import (
+	"stellar_journal/internal/lib/apod_date"
+	"stellar_journal/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCursor(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		cursor storage.Cursor
+		err    bool
+	}{
+		{name: "Empty", input: ""},
+		{name: "Date", input: "2024-01-02", cursor: storage.Cursor{Date: apod_date.MustParse("2024-01-02")}},
+		{name: "Date And Source", input: "2024-01-02.bing", cursor: storage.Cursor{Date: apod_date.MustParse("2024-01-02"), Source: "bing"}},
+		{name: "Invalid Date", input: "yesterday.bing", err: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, err := storage.ParseCursor(tc.input)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.cursor, cursor)
+			require.Equal(t, tc.input, cursor.String())
+		})
+	}
+}
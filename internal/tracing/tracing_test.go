@@ -0,0 +1,34 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"stellar_journal/internal/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdout(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:       tracing.ExporterStdout,
+		SampleRatio:    1,
+		ServiceName:    "stellar_journal",
+		ServiceVersion: "test",
+	}, &out)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "GET /journal/{date}")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	require.Contains(t, out.String(), `"Name": "GET /journal/{date}"`)
+	require.Contains(t, out.String(), `"Value": "stellar_journal"`)
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "jaeger"}, nil)
+	require.ErrorContains(t, err, "unknown exporter")
+}
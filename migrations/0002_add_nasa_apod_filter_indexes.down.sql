@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS nasa_apod_copyright_trgm_idx;
+DROP INDEX IF EXISTS nasa_apod_service_version_idx;
+DROP INDEX IF EXISTS nasa_apod_media_type_idx;
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS nasa_apod_media_type_idx ON nasa_apod (media_type);
+CREATE INDEX IF NOT EXISTS nasa_apod_service_version_idx ON nasa_apod (service_version);
+CREATE INDEX IF NOT EXISTS nasa_apod_copyright_trgm_idx ON nasa_apod USING GIN (copyright gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS nasa_apod_search_vector_idx;
+
+ALTER TABLE nasa_apod DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,8 @@
+ALTER TABLE nasa_apod
+	ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(explanation, '')), 'B')
+	) STORED;
+
+CREATE INDEX IF NOT EXISTS nasa_apod_search_vector_idx ON nasa_apod USING GIN (search_vector);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS apod_media (
+	id SERIAL PRIMARY KEY,
+	apod_id INTEGER NOT NULL REFERENCES nasa_apod (id) ON DELETE CASCADE,
+	variant TEXT NOT NULL,
+	source_url TEXT NOT NULL,
+	storage_key TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	byte_size BIGINT NOT NULL,
+	content_type TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	UNIQUE (apod_id, variant)
+);
@@ -0,0 +1 @@
+ALTER TABLE nasa_apod DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1 @@
+ALTER TABLE nasa_apod ADD COLUMN IF NOT EXISTS thumbnail_url TEXT NOT NULL DEFAULT '';
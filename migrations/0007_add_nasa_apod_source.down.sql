@@ -0,0 +1,8 @@
+DELETE FROM nasa_apod WHERE source <> 'nasa_apod';
+
+DROP INDEX IF EXISTS nasa_apod_apod_date_source_idx;
+
+ALTER TABLE nasa_apod DROP CONSTRAINT IF EXISTS nasa_apod_source_apod_date_key;
+ALTER TABLE nasa_apod ADD CONSTRAINT nasa_apod_apod_date_key UNIQUE (apod_date);
+
+ALTER TABLE nasa_apod DROP COLUMN IF EXISTS source;
@@ -0,0 +1,6 @@
+ALTER TABLE nasa_apod ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'nasa_apod';
+
+ALTER TABLE nasa_apod DROP CONSTRAINT IF EXISTS nasa_apod_apod_date_key;
+ALTER TABLE nasa_apod ADD CONSTRAINT nasa_apod_source_apod_date_key UNIQUE (source, apod_date);
+
+CREATE INDEX IF NOT EXISTS nasa_apod_apod_date_source_idx ON nasa_apod (apod_date, source);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS apod_image_derivatives_touch_apod ON apod_image_derivatives;
+DROP FUNCTION IF EXISTS apod_image_derivatives_touch_apod();
+
+DROP TRIGGER IF EXISTS nasa_apod_set_updated_at ON nasa_apod;
+DROP FUNCTION IF EXISTS nasa_apod_set_updated_at();
+
+ALTER TABLE nasa_apod DROP COLUMN IF EXISTS updated_at;
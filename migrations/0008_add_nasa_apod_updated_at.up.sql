@@ -0,0 +1,28 @@
+ALTER TABLE nasa_apod ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE OR REPLACE FUNCTION nasa_apod_set_updated_at() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER nasa_apod_set_updated_at
+	BEFORE UPDATE ON nasa_apod
+	FOR EACH ROW EXECUTE FUNCTION nasa_apod_set_updated_at();
+
+-- Derivatives are part of the journal entry, so changing them modifies it too.
+CREATE OR REPLACE FUNCTION apod_image_derivatives_touch_apod() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		UPDATE nasa_apod SET updated_at = now() WHERE id = OLD.apod_id;
+	ELSE
+		UPDATE nasa_apod SET updated_at = now() WHERE id = NEW.apod_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER apod_image_derivatives_touch_apod
+	AFTER INSERT OR UPDATE OR DELETE ON apod_image_derivatives
+	FOR EACH ROW EXECUTE FUNCTION apod_image_derivatives_touch_apod();
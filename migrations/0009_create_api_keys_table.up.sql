@@ -0,0 +1,21 @@
+-- Keys are stored as SHA-256 hashes; the prefix identifies a key in listings
+-- and logs without revealing it.
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	key_prefix TEXT NOT NULL,
+	key_hash BYTEA NOT NULL UNIQUE,
+	daily_quota INTEGER NOT NULL DEFAULT 0 CHECK (daily_quota >= 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_used_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ
+);
+
+-- Requests made with a key per UTC day. A daily_quota of 0 is unlimited, but
+-- the requests are counted all the same.
+CREATE TABLE IF NOT EXISTS api_key_usage (
+	api_key_id INTEGER NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+	day DATE NOT NULL,
+	requests BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (api_key_id, day)
+);
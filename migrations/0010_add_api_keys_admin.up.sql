@@ -0,0 +1,2 @@
+-- Admin keys may also use the /admin routes.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS nasa_apod_record_revision ON nasa_apod;
+DROP FUNCTION IF EXISTS nasa_apod_record_revision();
+DROP TABLE IF EXISTS nasa_apod_revisions;
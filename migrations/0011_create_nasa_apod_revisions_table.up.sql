@@ -0,0 +1,35 @@
+-- Prior versions of journal entries. A revision is recorded whenever an update
+-- changes the content of an entry; updated_at is when the version was last
+-- modified and replaced_at when the update replaced it.
+CREATE TABLE IF NOT EXISTS nasa_apod_revisions (
+	id SERIAL PRIMARY KEY,
+	apod_id INTEGER NOT NULL REFERENCES nasa_apod (id) ON DELETE CASCADE,
+	copyright TEXT,
+	explanation TEXT,
+	hdurl TEXT,
+	media_type TEXT,
+	service_version TEXT,
+	thumbnail_url TEXT,
+	title TEXT,
+	url TEXT,
+	updated_at TIMESTAMPTZ NOT NULL,
+	replaced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS nasa_apod_revisions_apod_id_idx ON nasa_apod_revisions (apod_id, replaced_at);
+
+CREATE OR REPLACE FUNCTION nasa_apod_record_revision() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO nasa_apod_revisions (apod_id, copyright, explanation, hdurl, media_type, service_version, thumbnail_url, title, url, updated_at)
+	VALUES (OLD.id, OLD.copyright, OLD.explanation, OLD.hdurl, OLD.media_type, OLD.service_version, OLD.thumbnail_url, OLD.title, OLD.url, OLD.updated_at);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Touching updated_at alone, as derivatives do, is not a new version.
+CREATE OR REPLACE TRIGGER nasa_apod_record_revision
+	AFTER UPDATE ON nasa_apod
+	FOR EACH ROW
+	WHEN ((OLD.copyright, OLD.explanation, OLD.hdurl, OLD.media_type, OLD.service_version, OLD.thumbnail_url, OLD.title, OLD.url)
+		IS DISTINCT FROM (NEW.copyright, NEW.explanation, NEW.hdurl, NEW.media_type, NEW.service_version, NEW.thumbnail_url, NEW.title, NEW.url))
+	EXECUTE FUNCTION nasa_apod_record_revision();
@@ -0,0 +1,7 @@
+-- Days a source has no picture for, recorded by backfills so that chunks with
+-- such days are known to be complete.
+CREATE TABLE IF NOT EXISTS apod_no_picture_days (
+	source TEXT NOT NULL,
+	apod_date DATE NOT NULL,
+	PRIMARY KEY (source, apod_date)
+);